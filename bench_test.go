@@ -0,0 +1,99 @@
+package goobfuscated
+
+import "testing"
+
+// newBenchObfuscator returns the seeded scheme measured by the benchmarks, of
+// the default width and encoding.
+func newBenchObfuscator(tb testing.TB) *Obfuscator {
+	tb.Helper()
+	o, err := NewObfuscator(WithSeed(20200101))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return o
+}
+
+func BenchmarkObfuscate(b *testing.B) {
+	o := newBenchObfuscator(b)
+	var n uint64
+	for i := range uint64(b.N) {
+		n += o.Obfuscate(i)
+	}
+	_ = n
+}
+
+func BenchmarkDeObfuscate(b *testing.B) {
+	o := newBenchObfuscator(b)
+	var n uint64
+	for i := range uint64(b.N) {
+		n += o.DeObfuscate(i & MaxInt)
+	}
+	_ = n
+}
+
+func BenchmarkString(b *testing.B) {
+	o := newBenchObfuscator(b)
+	b.ReportAllocs()
+	for i := range b.N {
+		_ = o.FormatID(ID(i))
+	}
+}
+
+func BenchmarkAppendString(b *testing.B) {
+	o := newBenchObfuscator(b)
+	b.ReportAllocs()
+	buf := make([]byte, 0, 64)
+	for i := range b.N {
+		buf = o.AppendString(buf[:0], ID(i))
+	}
+}
+
+func BenchmarkParseID(b *testing.B) {
+	o := newBenchObfuscator(b)
+	s := o.FormatID(100)
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := o.ParseID(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStrictParseID(b *testing.B) {
+	o := newBenchObfuscator(b)
+	s := o.FormatID(100)
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := o.StrictParseID(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// raceEnabled is set when the tests run with -race, see race_test.go.
+var raceEnabled bool
+
+func TestAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool allocates under the race detector")
+	}
+	o := newBenchObfuscator(t)
+	s := o.FormatID(100)
+	buf := make([]byte, 0, 64)
+	for _, tt := range []struct {
+		name string
+		max  float64
+		f    func()
+	}{
+		{"Obfuscate", 0, func() { o.Obfuscate(100) }},
+		{"DeObfuscate", 0, func() { o.DeObfuscate(100) }},
+		{"FormatID", 1, func() { _ = o.FormatID(100) }},
+		{"AppendString", 0, func() { buf = o.AppendString(buf[:0], 100) }},
+		{"ParseID", 0, func() { _, _ = o.ParseID(s) }},
+		{"StrictParseID", 0, func() { _, _ = o.StrictParseID(s) }},
+	} {
+		if got := testing.AllocsPerRun(100, tt.f); got > tt.max {
+			t.Errorf("%s allocates %v times, want at most %v", tt.name, got, tt.max)
+		}
+	}
+}
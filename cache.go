@@ -0,0 +1,64 @@
+package goobfuscated
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// parseCache is a bounded LRU cache of the ids returned by ParseID, see
+// WithParseCache. It is safe for concurrent use.
+type parseCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // most recently used first, of *cacheEntry.
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	s  string
+	id ID
+}
+
+func newParseCache(size int) *parseCache {
+	return &parseCache{size: size, order: list.New(), items: make(map[string]*list.Element, size)}
+}
+
+// get returns the cached id of s.
+func (c *parseCache) get(s string) (ID, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[s]
+	if !ok {
+		return 0, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).id, true
+}
+
+// add caches the id of s, evicting the least recently used one if the cache
+// is full.
+func (c *parseCache) add(s string, id ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[s]; ok {
+		e.Value.(*cacheEntry).id = id
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.order.Len() >= c.size {
+		e := c.order.Back()
+		delete(c.items, e.Value.(*cacheEntry).s)
+		c.order.Remove(e)
+	}
+	s = strings.Clone(s) // s may keep a larger buffer alive, e.g. a request body.
+	c.items[s] = c.order.PushFront(&cacheEntry{s: s, id: id})
+}
+
+// purge drops every cached id.
+func (c *parseCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.items)
+}
@@ -0,0 +1,54 @@
+package goobfuscated
+
+import "testing"
+
+func TestParseCache(t *testing.T) {
+	o, err := NewObfuscator(WithSeed(20200101), WithParseCache(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for round := range 2 {
+		for _, id := range []ID{1, 2, 3, 1} {
+			if got, err := o.ParseID(o.FormatID(id)); err != nil || got != id {
+				t.Fatalf("round %d: ParseID of %d returns %d, %v", round, id, got, err)
+			}
+		}
+	}
+	if n := len(o.cache.items); n != 2 {
+		t.Errorf("cache of size 2 holds %d strings", n)
+	}
+	if _, err := o.ParseID("bad"); err == nil {
+		t.Error("ParseID(\"bad\") succeeds")
+	}
+	if _, ok := o.cache.get("bad"); ok {
+		t.Error("a failed parse is cached")
+	}
+}
+
+// benchmarkParseCache parses keys distinct strings over and over, with a
+// cache of size strings, or without cache if size is 0.
+func benchmarkParseCache(b *testing.B, size, keys int) {
+	opts := []Option{WithSeed(20200101)}
+	if size > 0 {
+		opts = append(opts, WithParseCache(size))
+	}
+	o, err := NewObfuscator(opts...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ss := make([]string, keys)
+	for i := range ss {
+		ss[i] = o.FormatID(ID(i))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		if _, err := o.ParseID(ss[i%keys]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseCacheRepeated(b *testing.B) { benchmarkParseCache(b, 1024, 16) }
+func BenchmarkParseCacheMissed(b *testing.B)   { benchmarkParseCache(b, 16, 1024) }
+func BenchmarkParseCacheDisabled(b *testing.B) { benchmarkParseCache(b, 0, 16) }
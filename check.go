@@ -0,0 +1,78 @@
+package goobfuscated
+
+import "crypto/subtle"
+
+// FormatIDWithCheck is like FormatID, but it appends a CRC-8 of the obfuscated
+// bytes, the 9 bytes encode to 12 characters instead of 11 in base64. The
+// CRC-8 detects any change of at most 8 contiguous bits, so the guarantee
+// depends on the encoding:
+//
+//   - Base64, SortableBase64, Crockford, Hex and Phonetic map each character,
+//     or word, to a fixed group of at most 8 bits: every mistyped character is
+//     detected.
+//   - Base45 encodes 2 bytes per 3 characters and Base58 and Base62 encode the
+//     whole value as a number, a mistyped character changes many bits: it
+//     passes the check with a probability of 1/256.
+//
+// A dropped or extra character changes the length with the byte-aligned
+// encodings. Any other corruption passes the check with a probability of
+// 1/256.
+func (o *Obfuscator) FormatIDWithCheck(id ID) string {
+	buf := o.binary(o.Obfuscate(id.Uint64()))
+	return o.encode(append(buf, crc8(buf)))
+}
+
+// ParseIDWithCheck is an inverse operation of FormatIDWithCheck, it returns an
+// error if the checksum does not match.
+func (o *Obfuscator) ParseIDWithCheck(s string) (ID, error) {
+	buf, err := o.decode(s)
+	if err != nil {
+		return 0, err
+	}
+	if len(buf) != 9 { // ID expected to be exactly 8 bytes and the checksum.
+		return 0, newError(ErrInvalidLength, "unexpected id format")
+	}
+	if !o.checksumEqual(crc8(buf[:8]), buf[8]) {
+		return 0, newError(ErrInvalidFormat, "id checksum mismatch")
+	}
+	return o.parseBinary(buf[:8])
+}
+
+// StringWithCheck returns the obfuscated id with a checksum, it is 12
+// characters long, see Obfuscator.FormatIDWithCheck. It panics if the default
+// Obfuscator could not be created.
+func (id *ID) StringWithCheck() string { return mustDefault().FormatIDWithCheck(*id) }
+
+// ParseIDWithCheck is an inverse operation of ID.StringWithCheck().
+func ParseIDWithCheck(s string) (ID, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	return o.ParseIDWithCheck(s)
+}
+
+// checksumEqual reports whether the checksums a and b are equal, in constant
+// time for the keyed modes like equal.
+func (o *Obfuscator) checksumEqual(a, b byte) bool {
+	if !o.keyed() {
+		return a == b
+	}
+	return subtle.ConstantTimeByteEq(a, b) == 1
+}
+
+// crc8 returns the CRC-8 (polynomial x^8 + x^2 + x + 1) of buf.
+func crc8(buf []byte) byte {
+	var crc byte
+	for _, b := range buf {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
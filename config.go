@@ -0,0 +1,201 @@
+package goobfuscated
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Config is the serializable form of the scheme of an Obfuscator, it holds
+// everything needed to rebuild it: snapshot it with Obfuscator.Config, persist
+// it, e.g. as JSON in a secrets store, and rebuild the same scheme later with
+// Config.Obfuscator. The mask and the key are secrets.
+type Config struct {
+	Prime      uint64 `json:"prime"`
+	Mask       uint64 `json:"mask"`
+	Bits       int    `json:"bits"`
+	ByteOrder  string `json:"byte_order"`           // LittleEndian or BigEndian.
+	Encoding   string `json:"encoding"`             // base64 by default, see the names of encodings.
+	Mode       Mode   `json:"mode,omitempty"`       // Multiplicative unless set.
+	Rounds     int    `json:"rounds,omitempty"`     // rounds of the Feistel mode.
+	Key        []byte `json:"key,omitempty"`        // key of the Feistel and Keyed mode.
+	Prefix     string `json:"prefix,omitempty"`     // see WithPrefix.
+	MinLength  int    `json:"min_length,omitempty"` // see WithMinLength.
+	JSONNumber bool   `json:"json_number,omitempty"`
+	NonceBits  int    `json:"nonce_bits,omitempty"`  // see WithNonce.
+	Compact    bool   `json:"compact,omitempty"`     // see WithCompact.
+	TagBit     bool   `json:"tag_bit,omitempty"`     // see WithTagBit.
+	ZeroString string `json:"zero_string,omitempty"` // see WithZeroString.
+	ZeroSet    bool   `json:"zero_set,omitempty"`    // ZeroString is configured, it may be "".
+	FixedWidth bool   `json:"fixed_width,omitempty"` // see WithFixedWidth.
+	Version    uint64 `json:"version,omitempty"`     // see WithVersion.
+	VersionBit int    `json:"version_bit,omitempty"` // bits of the version, none unless set.
+}
+
+// encodings names the built-in encodings in a Config.
+var encodings = map[string]Encoding{
+	"base64":          Base64,
+	"base64-padded":   Base64Padded,
+	"crockford":       Crockford,
+	"base45":          Base45,
+	"base58":          Base58,
+	"base62":          Base62,
+	"hex":             Hex,
+	"phonetic":        Phonetic,
+	"sortable-base64": SortableBase64,
+}
+
+// Config returns the scheme of o. The mask and the key are taken as derived
+// by NewObfuscator, with the scheme of the domain already derived, so the
+// Config rebuilds the scheme without WithSeed or WithDomainSalt. A rotated
+// Obfuscator returns its primary scheme, the retired ones are not included.
+// A custom encoding has no name, it is left empty and must be configured
+// again when calling Config.Obfuscator.
+func (o *Obfuscator) Config() Config {
+	p := o.primary()
+	c := Config{
+		Prime:      p.prime,
+		Mask:       p.random,
+		Bits:       p.valueBits(),
+		ByteOrder:  o.order.String(),
+		Mode:       p.mode,
+		Prefix:     o.prefix,
+		MinLength:  o.minLen,
+		JSONNumber: o.jsonNumber,
+		NonceBits:  p.nonceBits,
+		Compact:    o.compact,
+		TagBit:     p.tag != 0,
+		ZeroString: o.zeroString,
+		ZeroSet:    o.zeroSet,
+		FixedWidth: o.width > 0,
+		VersionBit: p.versionBit,
+	}
+	if p.versionBit != 0 {
+		c.Version = p.version >> p.bits
+	}
+	if p.keyed() {
+		c.Rounds, c.Key = p.rounds, append([]byte(nil), p.key...)
+	}
+	for name, enc := range encodings {
+		if enc == o.enc {
+			c.Encoding = name
+		}
+	}
+	return c
+}
+
+// ParseConfig parses the JSON form of a Config, as produced by json.Marshal.
+func ParseConfig(b []byte) (Config, error) {
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Config{}, fmt.Errorf("fails to parse config: %w", err)
+	}
+	return c, nil
+}
+
+// Validate checks c without building its Obfuscator, e.g. to fail fast at
+// startup on a broken config: the prime must be a prime coprime with the
+// modulus 1<<Bits, the mask must be in the id space, the width must be in the
+// range [MinBits,MaxBits] and the byte order and the encoding must be known.
+// It returns the problems found joined by errors.Join, or nil.
+func (c Config) Validate() error {
+	var errs []error
+	bitsOK := c.Bits >= MinBits && c.Bits <= MaxBits
+	if !bitsOK {
+		errs = append(errs, fmt.Errorf("bits %d is out of range [%d,%d]", c.Bits, MinBits, MaxBits))
+	}
+	if c.TagBit && bitsOK && c.Bits <= MinBits {
+		errs = append(errs, fmt.Errorf("tag bit leaves less than %d bits of the %d bits id space", MinBits, c.Bits))
+		bitsOK = false
+	}
+	switch {
+	case c.Prime == 0:
+		errs = append(errs, errors.New("config has no prime"))
+	case !isPrime(c.Prime):
+		errs = append(errs, fmt.Errorf("%d is not a valid prime. [Accuracy: %f]", c.Prime, accuracy()))
+	case c.Prime%2 == 0:
+		errs = append(errs, fmt.Errorf("prime %d is not coprime with the modulus", c.Prime))
+	}
+	if bitsOK {
+		max := uint64(1)<<c.Bits - 1
+		if c.TagBit {
+			max >>= 1
+		}
+		max >>= c.VersionBit
+		if c.Mask == 0 || c.Mask > max {
+			errs = append(errs, fmt.Errorf("mask %d is out of range [1,%d]", c.Mask, max))
+		}
+	}
+	switch c.ByteOrder {
+	case binary.LittleEndian.String(), binary.BigEndian.String():
+	default:
+		errs = append(errs, fmt.Errorf("unknown byte order %q", c.ByteOrder))
+	}
+	if _, ok := encodings[c.Encoding]; c.Encoding != "" && !ok {
+		errs = append(errs, fmt.Errorf("unknown encoding %q", c.Encoding))
+	}
+	return errors.Join(errs...)
+}
+
+// Obfuscator rebuilds the Obfuscator of c. opts are applied after the options
+// derived from c, e.g. to configure a custom encoding WithEncoding.
+func (c Config) Obfuscator(opts ...Option) (*Obfuscator, error) {
+	var order binary.ByteOrder
+	switch c.ByteOrder {
+	case binary.LittleEndian.String():
+		order = binary.LittleEndian
+	case binary.BigEndian.String():
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("unknown byte order %q", c.ByteOrder)
+	}
+	enc := Base64
+	if c.Encoding != "" {
+		var ok bool
+		if enc, ok = encodings[c.Encoding]; !ok {
+			return nil, fmt.Errorf("unknown encoding %q", c.Encoding)
+		}
+	}
+	if c.Prime == 0 {
+		return nil, errors.New("config has no prime")
+	}
+	base := []Option{
+		WithPrime(c.Prime),
+		WithMask(c.Mask),
+		WithBits(c.Bits),
+		WithByteOrder(order),
+		WithEncoding(enc),
+		WithMode(c.Mode),
+		WithPrefix(c.Prefix),
+		WithMinLength(c.MinLength),
+	}
+	if len(c.Key) > 0 {
+		base = append(base, WithKey(c.Key))
+	}
+	if c.Mode == Feistel {
+		base = append(base, WithFeistel(c.Rounds, c.Key))
+	}
+	if c.JSONNumber {
+		base = append(base, WithJSONNumber())
+	}
+	if c.NonceBits != 0 {
+		base = append(base, WithNonce(c.NonceBits))
+	}
+	if c.Compact {
+		base = append(base, WithCompact())
+	}
+	if c.TagBit {
+		base = append(base, WithTagBit())
+	}
+	if c.ZeroSet {
+		base = append(base, WithZeroString(c.ZeroString))
+	}
+	if c.FixedWidth {
+		base = append(base, WithFixedWidth())
+	}
+	if c.VersionBit != 0 {
+		base = append(base, WithVersion(c.Version, c.VersionBit))
+	}
+	return NewObfuscator(append(base, opts...)...)
+}
@@ -0,0 +1,69 @@
+package goobfuscated
+
+// cursorVersion is the version of the cursor format, the first byte of a
+// cursor. DecodeCursor rejects the cursors of other versions.
+const cursorVersion = 1
+
+// cursorBackward is the flag of the cursors paginating backward.
+const cursorBackward = 1 << 0
+
+// Cursor is the boundary of a keyset pagination, e.g. WHERE id > ?, exposed to
+// clients as an opaque string.
+type Cursor struct {
+	ID       ID   // last id of the page.
+	Backward bool // the next page holds the ids before ID, WHERE id < ?.
+}
+
+// EncodeCursor returns the string form of c. The cursor holds a version byte,
+// a flags byte and the 8 bytes of the obfuscated id, so it is 14 characters
+// long in base64, 3 more than the id, see FormatID. The version allows the
+// format to evolve while the cursors handed out keep decoding.
+func (o *Obfuscator) EncodeCursor(c Cursor) string {
+	buf := make([]byte, 10)
+	buf[0] = cursorVersion
+	if c.Backward {
+		buf[1] |= cursorBackward
+	}
+	o.order.PutUint64(buf[2:], o.Obfuscate(c.ID.Uint64()))
+	return o.encode(buf)
+}
+
+// DecodeCursor is an inverse operation of EncodeCursor. It returns an error if
+// s is not a cursor of a known version, or if its id is out of range like
+// StrictParseID.
+func (o *Obfuscator) DecodeCursor(s string) (Cursor, error) {
+	buf, err := o.decode(s)
+	if err != nil {
+		return Cursor{}, err
+	}
+	if len(buf) != 10 { // version, flags and the 8 bytes of the id.
+		return Cursor{}, newError(ErrInvalidLength, "unexpected cursor format")
+	}
+	if buf[0] != cursorVersion {
+		return Cursor{}, newError(ErrInvalidFormat, "unsupported cursor version %d", buf[0])
+	}
+	if buf[1]&^cursorBackward != 0 {
+		return Cursor{}, newError(ErrInvalidFormat, "unsupported cursor flags %#x", buf[1])
+	}
+	id, ok := o.deObfuscate(o.order.Uint64(buf[2:]))
+	if !ok {
+		return Cursor{}, ErrOutOfRange
+	}
+	return Cursor{ID: ID(id), Backward: buf[1]&cursorBackward != 0}, nil
+}
+
+// EncodeCursor returns the forward cursor after id encoded by the default
+// Obfuscator, see Obfuscator.EncodeCursor. It panics if the default could
+// not be created.
+func EncodeCursor(id ID) string { return mustDefault().EncodeCursor(Cursor{ID: id}) }
+
+// DecodeCursor is an inverse operation of EncodeCursor, it returns the id of
+// the cursor. Use Obfuscator.DecodeCursor to read the direction as well.
+func DecodeCursor(s string) (ID, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	c, err := o.DecodeCursor(s)
+	return c.ID, err
+}
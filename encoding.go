@@ -0,0 +1,294 @@
+package goobfuscated
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Encoding encodes the bytes of the obfuscated id into a string and back,
+// *base64.Encoding satisfies it. Custom encodings, e.g. with a company
+// specific alphabet, can be configured WithEncoding.
+//
+// An Encoding must round-trip exactly: DecodeString(EncodeToString(src)) must
+// return src for any src, in particular for the 8 bytes of an id including
+// those with leading or trailing zero bytes. NewObfuscator rejects an
+// encoding which fails to round-trip a few such buffers.
+type Encoding interface {
+	EncodeToString(src []byte) string
+	DecodeString(s string) ([]byte, error)
+}
+
+// appendEncoding is implemented by the encodings which can encode into and
+// decode from a buffer of the caller, e.g. *base64.Encoding. It saves the
+// allocations of EncodeToString and DecodeString.
+type appendEncoding interface {
+	AppendEncode(dst, src []byte) []byte
+	AppendDecode(dst, src []byte) ([]byte, error)
+}
+
+// Base64 is the default encoding, base64.RawURLEncoding. An id is 11
+// characters long.
+var Base64 Encoding = base64.RawURLEncoding
+
+// Base64Padded is base64.URLEncoding, Base64 with "=" padding, an id is 12
+// characters long. Decoding accepts ids with and without padding, see
+// WithPadding.
+var Base64Padded Encoding = paddedBase64{}
+
+type paddedBase64 struct{}
+
+func (paddedBase64) EncodeToString(src []byte) string { return base64.URLEncoding.EncodeToString(src) }
+
+func (paddedBase64) DecodeString(s string) ([]byte, error) {
+	if len(s)%4 == 0 {
+		return base64.URLEncoding.DecodeString(s)
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func (paddedBase64) AppendEncode(dst, src []byte) []byte {
+	return base64.URLEncoding.AppendEncode(dst, src)
+}
+
+func (paddedBase64) AppendDecode(dst, src []byte) ([]byte, error) {
+	if len(src)%4 == 0 {
+		return base64.URLEncoding.AppendDecode(dst, src)
+	}
+	return base64.RawURLEncoding.AppendDecode(dst, src)
+}
+
+// verifyEncoding returns an error if enc does not round-trip some 8 bytes
+// buffers.
+func verifyEncoding(enc Encoding) error {
+	for _, src := range [][]byte{
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0, 0, 1},
+		{1, 0, 0, 0, 0, 0, 0, 0},
+		{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef},
+		{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	} {
+		buf, err := enc.DecodeString(enc.EncodeToString(src))
+		if err != nil {
+			return fmt.Errorf("encoding does not round-trip %x: %w", src, err)
+		}
+		if !bytes.Equal(buf, src) {
+			return fmt.Errorf("encoding does not round-trip %x: got %x", src, buf)
+		}
+	}
+	return nil
+}
+
+// encodedLen returns the length of the longest string of n bytes encoded by
+// enc, which is the one of n 0xff bytes unless the symbols have distinct
+// lengths, e.g. the words of Phonetic.
+func encodedLen(enc Encoding, n int) int {
+	if enc == Phonetic {
+		longest := 0
+		for _, w := range phoneticWords {
+			longest = max(longest, len(w))
+		}
+		return n*longest + n - 1
+	}
+	return len(enc.EncodeToString(bytes.Repeat([]byte{0xff}, n)))
+}
+
+// Crockford is Douglas Crockford's Base32, it uses the digits and the upper
+// case letters except I, L, O and U, so ids can be read aloud and typed
+// without confusion. An id is 13 characters long. Decoding is case-insensitive,
+// accepts O for 0 and I or L for 1, and ignores hyphens.
+//
+// See: https://www.crockford.com/base32.html
+var Crockford Encoding = crockford{base32.NewEncoding("0123456789ABCDEFGHJKMNPQRSTVWXYZ").WithPadding(base32.NoPadding)}
+
+type crockford struct{ *base32.Encoding }
+
+// crockfordReplacer normalizes the input of crockford.DecodeString.
+var crockfordReplacer = strings.NewReplacer("O", "0", "I", "1", "L", "1", "-", "")
+
+func (e crockford) DecodeString(s string) ([]byte, error) {
+	return e.Encoding.DecodeString(crockfordReplacer.Replace(strings.ToUpper(s)))
+}
+
+// AppendDecode overrides the one of base32.Encoding to normalize src too.
+func (e crockford) AppendDecode(dst, src []byte) ([]byte, error) {
+	return e.Encoding.AppendDecode(dst, []byte(crockfordReplacer.Replace(strings.ToUpper(string(src)))))
+}
+
+// Hex is the lower case hexadecimal encoding, it is handy to compare an id
+// with a hex dump of the obfuscated bytes. An id is 16 characters long.
+// Decoding is case-insensitive.
+var Hex Encoding = hexEncoding{}
+
+type hexEncoding struct{}
+
+func (hexEncoding) EncodeToString(src []byte) string { return hex.EncodeToString(src) }
+
+func (hexEncoding) DecodeString(s string) ([]byte, error) { return hex.DecodeString(s) }
+
+func (hexEncoding) AppendEncode(dst, src []byte) []byte { return hex.AppendEncode(dst, src) }
+
+func (hexEncoding) AppendDecode(dst, src []byte) ([]byte, error) { return hex.AppendDecode(dst, src) }
+
+// Base45 is the encoding of RFC 9285, its alphabet is the alphanumeric mode of
+// QR codes, the digits, the upper case letters, the space and $%*+-./:, so an
+// id embeds in a QR code at 5.5 bits per character instead of 8 in the byte
+// mode. An id is 12 characters long, 3 for every 2 bytes. Decoding is
+// case-insensitive. The space and some of the symbols must be escaped in
+// URLs, and the prefix, if any, must be in the alphabet too for the QR code
+// to stay in the alphanumeric mode.
+//
+// See: https://www.rfc-editor.org/rfc/rfc9285
+var Base45 Encoding = base45{}
+
+const base45Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// base45Index is the index of each character in base45Alphabet, -1 if none.
+// The lower case letters map to the upper case ones.
+var base45Index = func() (index [256]int8) {
+	for i := range index {
+		index[i] = -1
+	}
+	for i := 0; i < len(base45Alphabet); i++ {
+		index[base45Alphabet[i]] = int8(i)
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		index[c] = index[c-'a'+'A']
+	}
+	return index
+}()
+
+type base45 struct{}
+
+func (e base45) EncodeToString(src []byte) string { return string(e.AppendEncode(nil, src)) }
+
+func (e base45) DecodeString(s string) ([]byte, error) { return e.AppendDecode(nil, []byte(s)) }
+
+func (base45) AppendEncode(dst, src []byte) []byte {
+	for ; len(src) >= 2; src = src[2:] {
+		n := int(src[0])<<8 | int(src[1])
+		dst = append(dst, base45Alphabet[n%45], base45Alphabet[n/45%45], base45Alphabet[n/(45*45)])
+	}
+	if len(src) == 1 {
+		dst = append(dst, base45Alphabet[src[0]%45], base45Alphabet[src[0]/45])
+	}
+	return dst
+}
+
+func (base45) AppendDecode(dst, src []byte) ([]byte, error) {
+	if len(src)%3 == 1 {
+		return dst, fmt.Errorf("invalid base45 length %d", len(src))
+	}
+	for i := 0; i < len(src); i += 3 {
+		chunk := src[i:min(i+3, len(src))]
+		n := 0
+		for j := len(chunk) - 1; j >= 0; j-- {
+			d := base45Index[chunk[j]]
+			if d < 0 {
+				return dst, fmt.Errorf("invalid base45 character %q", chunk[j])
+			}
+			n = n*45 + int(d)
+		}
+		switch {
+		case len(chunk) == 3 && n <= 0xffff:
+			dst = append(dst, byte(n>>8), byte(n))
+		case len(chunk) == 2 && n <= 0xff:
+			dst = append(dst, byte(n))
+		default:
+			return dst, fmt.Errorf("invalid base45 chunk %q", chunk)
+		}
+	}
+	return dst, nil
+}
+
+// Base58 is the Base58 encoding with the Bitcoin alphabet, it has neither
+// symbols nor the look-alike characters 0, O, I and l. Base58 is not byte
+// aligned, each leading zero byte is encoded as a leading '1' so that the
+// bytes round-trip exactly, an id is up to 11 characters long. It is slower
+// than base64 since it divides the whole buffer for every character.
+var Base58 Encoding = newBaseN("123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz")
+
+// Base62 is the encoding with the digits and the ASCII letters, for strings
+// which must not hold any symbol, e.g. the links of emails which mangle '-'
+// and '_'. Like Base58, it is not byte aligned: each leading zero byte is
+// encoded as a leading '0' so that the bytes round-trip exactly, the zero
+// value included, and an id is up to 11 characters long, use WithFixedWidth
+// for ids of a constant length. It is as slow as Base58.
+var Base62 Encoding = newBaseN("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+
+// baseN encodes bytes as a big-endian number in the base of its alphabet,
+// leading zero bytes are encoded as the first character of the alphabet.
+type baseN struct {
+	alphabet string
+	index    [256]int16 // index of each character in alphabet, -1 if none.
+}
+
+func newBaseN(alphabet string) *baseN {
+	e := &baseN{alphabet: alphabet}
+	for i := range e.index {
+		e.index[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		e.index[alphabet[i]] = int16(i)
+	}
+	return e
+}
+
+func (e *baseN) EncodeToString(src []byte) string {
+	base := len(e.alphabet)
+	zeros := 0
+	for zeros < len(src) && src[zeros] == 0 {
+		zeros++
+	}
+	// digits holds the number in little-endian order.
+	var digits []byte
+	for _, b := range src[zeros:] {
+		carry := int(b)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i], carry = byte(carry%base), carry/base
+		}
+		for ; carry > 0; carry /= base {
+			digits = append(digits, byte(carry%base))
+		}
+	}
+	out := make([]byte, zeros+len(digits))
+	for i := 0; i < zeros; i++ {
+		out[i] = e.alphabet[0]
+	}
+	for i, d := range digits {
+		out[len(out)-1-i] = e.alphabet[d]
+	}
+	return string(out)
+}
+
+func (e *baseN) DecodeString(s string) ([]byte, error) {
+	base := len(e.alphabet)
+	zeros := 0
+	for zeros < len(s) && s[zeros] == e.alphabet[0] {
+		zeros++
+	}
+	// num holds the bytes in little-endian order.
+	var num []byte
+	for i := zeros; i < len(s); i++ {
+		carry := int(e.index[s[i]])
+		if carry < 0 {
+			return nil, fmt.Errorf("illegal character %q at input byte %d", s[i], i)
+		}
+		for j := range num {
+			carry += int(num[j]) * base
+			num[j], carry = byte(carry), carry>>8
+		}
+		for ; carry > 0; carry >>= 8 {
+			num = append(num, byte(carry))
+		}
+	}
+	out := make([]byte, zeros+len(num))
+	for i, b := range num {
+		out[len(out)-1-i] = b
+	}
+	return out, nil
+}
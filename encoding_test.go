@@ -0,0 +1,138 @@
+package goobfuscated
+
+import (
+	"bytes"
+	"testing"
+)
+
+// testRoundTrip checks that enc decodes what it encodes, zero bytes included,
+// and that the ids of an Obfuscator of enc round-trip, zero included.
+func testRoundTrip(t *testing.T, enc Encoding) {
+	t.Helper()
+	for _, src := range [][]byte{
+		{},
+		{0},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 0, 1},
+		{1, 0, 0},
+		{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	} {
+		s := enc.EncodeToString(src)
+		if got, err := enc.DecodeString(s); err != nil || !bytes.Equal(got, src) {
+			t.Errorf("%x encodes to %q which decodes to %x, %v", src, s, got, err)
+		}
+	}
+	o, err := NewObfuscator(WithSeed(20200101), WithEncoding(enc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []ID{0, 1, 2, 100, MaxInt - 1, MaxInt} {
+		s := o.FormatID(id)
+		if got, err := o.StrictParseID(s); err != nil || got != id {
+			t.Errorf("%d formats to %q which parses to %d, %v", id, s, got, err)
+		}
+	}
+	// The zero value itself, whatever id it is the value of.
+	zero := o.DeObfuscate(0)
+	if s := o.FormatID(ID(zero)); s != enc.EncodeToString(make([]byte, 8)) {
+		t.Errorf("the zero value of %d formats to %q", zero, s)
+	}
+}
+
+// benchmarkFormatID measures FormatID of a scheme of enc.
+func benchmarkFormatID(b *testing.B, enc Encoding) {
+	o, err := NewObfuscator(WithSeed(20200101), WithEncoding(enc))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := range b.N {
+		_ = o.FormatID(ID(i))
+	}
+}
+
+// benchmarkParseID measures ParseID of a scheme of enc.
+func benchmarkParseID(b *testing.B, enc Encoding) {
+	o, err := NewObfuscator(WithSeed(20200101), WithEncoding(enc))
+	if err != nil {
+		b.Fatal(err)
+	}
+	s := o.FormatID(100)
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := o.ParseID(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBase58(t *testing.T) { testRoundTrip(t, Base58) }
+
+func BenchmarkFormatIDBase64(b *testing.B) { benchmarkFormatID(b, Base64) }
+func BenchmarkFormatIDBase58(b *testing.B) { benchmarkFormatID(b, Base58) }
+func BenchmarkParseIDBase64(b *testing.B)  { benchmarkParseID(b, Base64) }
+func BenchmarkParseIDBase58(b *testing.B)  { benchmarkParseID(b, Base58) }
+
+func TestBase62(t *testing.T) { testRoundTrip(t, Base62) }
+
+func BenchmarkFormatIDBase62(b *testing.B) { benchmarkFormatID(b, Base62) }
+func BenchmarkParseIDBase62(b *testing.B)  { benchmarkParseID(b, Base62) }
+
+func TestFixedWidth(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		enc   Encoding
+		width int
+	}{
+		{"base64", Base64, 11},
+		{"crockford", Crockford, 13},
+		{"hex", Hex, 16},
+		{"base58", Base58, 11},
+		{"base62", Base62, 11},
+	} {
+		o, err := NewObfuscator(WithSeed(20200101), WithEncoding(tt.enc), WithFixedWidth())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		// The ids of the obfuscated values at the bottom and at the top of
+		// the range, the shortest and the longest encoded ones, and the ids
+		// at both ends of the range.
+		ids := []ID{0, 1, MaxInt - 1, MaxInt}
+		for _, n := range []uint64{0, 1, 0xff, MaxInt >> 8, MaxInt - 1, MaxInt} {
+			ids = append(ids, ID(o.DeObfuscate(n)))
+		}
+		for _, id := range ids {
+			s := o.FormatID(id)
+			if len(s) != tt.width {
+				t.Errorf("%s: %d formats to %q of %d characters, want %d", tt.name, id, s, len(s), tt.width)
+			}
+			if got, err := o.StrictParseID(s); err != nil || got != id {
+				t.Errorf("%s: %q parses to %d, %v, want %d", tt.name, s, got, err, id)
+			}
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	o, err := NewObfuscator(WithSeed(20200101), WithCompact())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		id   ID
+		want string
+	}{
+		{0, "CnLznm_mSQ"},
+		{MaxInt, "FY0Me2_qDg"},
+		{ID(o.DeObfuscate(0)), "AA"}, // the zero value keeps one byte.
+		{ID(o.DeObfuscate(1)), "AQ"},
+		{ID(o.DeObfuscate(MaxInt)), "H________w"},
+	} {
+		if got := o.FormatID(tt.id); got != tt.want {
+			t.Errorf("FormatID(%d) = %q, want %q", tt.id, got, tt.want)
+		}
+		if got, err := o.StrictParseID(tt.want); err != nil || got != tt.id {
+			t.Errorf("%q parses to %d, %v, want %d", tt.want, got, err, tt.id)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package goobfuscated
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The errors returned by the package match one of these sentinel errors with
+// errors.Is, e.g. to answer a client sending a malformed id with 400 Bad
+// Request. The messages of the returned errors tell the details.
+var (
+	// ErrInvalidFormat matches the errors of strings which are not ids: a
+	// missing prefix, a character outside of the encoding, a wrong padding
+	// or checksum.
+	ErrInvalidFormat = errors.New("invalid id format")
+
+	// ErrInvalidLength matches the errors of strings or bytes which decode to
+	// another length than the one of an id.
+	ErrInvalidLength = errors.New("invalid id length")
+
+	// ErrOutOfRange matches the errors of ids or obfuscated values outside of
+	// the id space of the scheme.
+	ErrOutOfRange = errors.New("id is out of range")
+
+	// ErrExpired matches the errors of the strings of FormatIDWithTTL whose
+	// time to live has elapsed, they are otherwise well-formed.
+	ErrExpired = errors.New("id has expired")
+)
+
+// idError is an error with its own message which matches a sentinel error,
+// and the error it wraps, if any.
+type idError struct {
+	msg  string
+	kind error
+	err  error
+}
+
+func (e *idError) Error() string { return e.msg }
+
+func (e *idError) Unwrap() []error {
+	if e.err == nil {
+		return []error{e.kind}
+	}
+	return []error{e.kind, e.err}
+}
+
+// newError returns an error formatted like fmt.Errorf which matches kind.
+func newError(kind error, format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	return &idError{msg: err.Error(), kind: kind, err: errors.Unwrap(err)}
+}
@@ -0,0 +1,54 @@
+package goobfuscated_test
+
+import (
+	"fmt"
+
+	obfuscated "github.com/19byte/goobfuscated"
+)
+
+// The round trip of keys through Permute and Unpermute, with the prime and
+// the mask of NewTestObfuscator in the 53 bits space of the ids: the values
+// are the ones NewTestObfuscator obfuscates the ids to.
+func ExamplePermute() {
+	const mask = 1<<53 - 1
+	inv, err := obfuscated.ModInverse(obfuscated.TestPrime, mask+1)
+	if err != nil {
+		panic(err)
+	}
+	for _, v := range []uint64{0, 1, 42, mask} {
+		n := obfuscated.Permute(v, obfuscated.TestPrime, mask, obfuscated.TestMask)
+		fmt.Println(v, n, obfuscated.Unpermute(n, inv, mask, obfuscated.TestMask))
+	}
+	// Output:
+	// 0 34344076745517 0
+	// 1 34343640725912 1
+	// 42 34326310136479 42
+	// 9007199254740991 8972855614015078 9007199254740991
+}
+
+// Permute and Unpermute work in any space of 1<<n keys, here a byte.
+func ExampleUnpermute() {
+	const prime, mask, xor = 151, 0xff, 0x5a
+	inv, err := obfuscated.ModInverse(prime, mask+1)
+	if err != nil {
+		panic(err)
+	}
+	for v := uint64(0); v < 4; v++ {
+		n := obfuscated.Permute(v, prime, mask, xor)
+		fmt.Println(v, n, obfuscated.Unpermute(n, inv, mask, xor))
+	}
+	// Output:
+	// 0 90 0
+	// 1 205 1
+	// 2 116 2
+	// 3 159 3
+}
+
+func ExampleObfuscator() {
+	o := obfuscated.NewTestObfuscator()
+	s := o.FormatID(1)
+	id, err := o.StrictParseID(s)
+	fmt.Println(s, id.Uint64(), err)
+	// Output:
+	// mHmBQDwfAAA 1 <nil>
+}
@@ -0,0 +1,105 @@
+package goobfuscated
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"sync"
+)
+
+// permutation is a bijection over the id space of an Obfuscator, it replaces
+// Knuth's multiplicative hashing in the alternative modes.
+type permutation interface {
+	forward(x uint64) uint64
+	backward(x uint64) uint64
+}
+
+// feistel is a balanced Feistel network over [0,max]. The network works on
+// an even number of bits, so for odd widths it is one bit wider than the id
+// space and cycle walking is used to stay inside of it: the network is
+// applied again until the value is not greater than max, which keeps it a
+// bijection over [0,max].
+type feistel struct {
+	rounds int
+	f      func(i int, x uint64) uint64 // round function of round i.
+	half   uint                         // width of each half.
+	max    uint64
+}
+
+// newFeistel returns a Feistel network over bits with rounds rounds, using
+// round keys derived from key.
+func newFeistel(bits, rounds int, key []byte) *feistel {
+	keys := make([]uint64, rounds)
+	for i := range keys {
+		h := sha256.New()
+		h.Write(key)
+		h.Write([]byte{byte(i)})
+		keys[i] = binary.LittleEndian.Uint64(h.Sum(nil))
+	}
+	f := func(i int, x uint64) uint64 { return round(x, keys[i]) }
+	return &feistel{rounds: rounds, f: f, half: uint(bits+1) / 2, max: 1<<bits - 1}
+}
+
+// keyedRounds is the number of rounds of the keyed mode, as in FF1.
+const keyedRounds = 10
+
+// newKeyed returns the Feistel network of the keyed mode. Its round function
+// is HMAC-SHA256 keyed by key, in the fashion of the FF1 format preserving
+// encryption, so the permutation can not be recovered without the key.
+func newKeyed(bits int, key []byte) *feistel {
+	pool := &sync.Pool{New: func() any { return hmac.New(sha256.New, key) }}
+	f := func(i int, x uint64) uint64 {
+		var buf [9]byte
+		buf[0] = byte(i)
+		binary.LittleEndian.PutUint64(buf[1:], x)
+		h := pool.Get().(hash.Hash)
+		h.Reset()
+		h.Write(buf[:])
+		sum := h.Sum(make([]byte, 0, sha256.Size))
+		pool.Put(h)
+		return binary.LittleEndian.Uint64(sum)
+	}
+	return &feistel{rounds: keyedRounds, f: f, half: uint(bits+1) / 2, max: 1<<bits - 1}
+}
+
+func (f *feistel) forward(x uint64) uint64 {
+	for x = f.encrypt(x & f.max); x > f.max; x = f.encrypt(x) {
+	}
+	return x
+}
+
+func (f *feistel) backward(x uint64) uint64 {
+	for x = f.decrypt(x & f.max); x > f.max; x = f.decrypt(x) {
+	}
+	return x
+}
+
+// encrypt runs x through each round of the network.
+func (f *feistel) encrypt(x uint64) uint64 {
+	mask := uint64(1)<<f.half - 1
+	l, r := x>>f.half, x&mask
+	for i := 0; i < f.rounds; i++ {
+		l, r = r, l^f.f(i, r)&mask
+	}
+	return l<<f.half | r
+}
+
+// decrypt is an inverse operation of encrypt.
+func (f *feistel) decrypt(x uint64) uint64 {
+	mask := uint64(1)<<f.half - 1
+	l, r := x>>f.half, x&mask
+	for i := f.rounds - 1; i >= 0; i-- {
+		l, r = r^f.f(i, l)&mask, l
+	}
+	return l<<f.half | r
+}
+
+// round is the round function of the network, it mixes x with the round key
+// using the splitmix64 finalizer.
+func round(x, k uint64) uint64 {
+	z := x ^ k
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
@@ -0,0 +1,93 @@
+package goobfuscated
+
+import "testing"
+
+func newFeistelObfuscator(tb testing.TB, bits int) *Obfuscator {
+	tb.Helper()
+	o, err := NewObfuscator(WithSeed(20200101), WithBits(bits), WithFeistel(8, []byte("feistel key")))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return o
+}
+
+func TestFeistel(t *testing.T) {
+	for _, bits := range []int{8, 9, 53, 64} {
+		o := newFeistelObfuscator(t, bits)
+		seen := make(map[uint64]bool)
+		for id := uint64(0); id < 256; id++ {
+			n := o.Obfuscate(id)
+			if n > o.limit() {
+				t.Fatalf("bits %d: %d obfuscates to %d, out of range", bits, id, n)
+			}
+			if seen[n] {
+				t.Fatalf("bits %d: %d obfuscates to %d, already taken", bits, id, n)
+			}
+			seen[n] = true
+			if got := o.DeObfuscate(n); got != id {
+				t.Fatalf("bits %d: %d deobfuscates to %d, want %d", bits, n, got, id)
+			}
+		}
+	}
+}
+
+func newKeyedObfuscator(tb testing.TB, bits int, key string) *Obfuscator {
+	tb.Helper()
+	o, err := NewObfuscator(WithSeed(20200101), WithBits(bits), WithMode(Keyed), WithKey([]byte(key)))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return o
+}
+
+func TestKeyed(t *testing.T) {
+	for _, bits := range []int{8, 9, 53, 64} {
+		o := newKeyedObfuscator(t, bits, "keyed key")
+		other := newKeyedObfuscator(t, bits, "keyed kex")
+		seen := make(map[uint64]bool)
+		same := 0
+		for id := uint64(0); id < 256; id++ {
+			n := o.Obfuscate(id)
+			if n > o.limit() {
+				t.Fatalf("bits %d: %d obfuscates to %d, out of range", bits, id, n)
+			}
+			if seen[n] {
+				t.Fatalf("bits %d: %d obfuscates to %d, already taken", bits, id, n)
+			}
+			seen[n] = true
+			if got := o.DeObfuscate(n); got != id {
+				t.Fatalf("bits %d: %d deobfuscates to %d, want %d", bits, n, got, id)
+			}
+			if other.Obfuscate(id) == n {
+				same++
+			}
+		}
+		// The ids of 8 bits collide by chance once in 256.
+		if same > 8 {
+			t.Errorf("bits %d: %d of 256 ids obfuscate the same with a key one bit apart", bits, same)
+		}
+	}
+	if _, err := NewObfuscator(WithMode(Keyed)); err == nil {
+		t.Error("the keyed mode is accepted without a key")
+	}
+}
+
+// BenchmarkFeistelObfuscate and BenchmarkFeistelDeObfuscate compare with
+// BenchmarkObfuscate and BenchmarkDeObfuscate of the Multiplicative mode.
+func BenchmarkFeistelObfuscate(b *testing.B) {
+	o := newFeistelObfuscator(b, 53)
+	var n uint64
+	for i := range uint64(b.N) {
+		n += o.Obfuscate(i)
+	}
+	_ = n
+}
+
+func BenchmarkFeistelDeObfuscate(b *testing.B) {
+	o := newFeistelObfuscator(b, 53)
+	var n uint64
+	for i := range uint64(b.N) {
+		n += o.DeObfuscate(i & MaxInt)
+	}
+	_ = n
+}
@@ -0,0 +1,33 @@
+package goobfuscated
+
+// Integer is satisfied by every integer type, including the named ones such
+// as type UserID int64. It matches constraints.Integer of golang.org/x/exp.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// ObfuscateT is like ObfuscateChecked for any integer type, it returns an
+// error if v is negative or greater than the upper bound of the default
+// Obfuscator, MaxInt unless configured.
+func ObfuscateT[T Integer](v T) (uint64, error) {
+	if v < 0 {
+		return 0, newError(ErrOutOfRange, "id %d is negative", v)
+	}
+	return ObfuscateChecked(uint64(v))
+}
+
+// DeObfuscateT is an inverse operation of ObfuscateT, it returns an error if
+// the deobfuscated id does not fit in T.
+func DeObfuscateT[T Integer](n uint64) (T, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	id := o.DeObfuscate(n)
+	if v := T(id); v >= 0 && uint64(v) == id {
+		return v, nil
+	}
+	var zero T
+	return 0, newError(ErrOutOfRange, "id %d overflows %T", id, zero)
+}
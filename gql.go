@@ -0,0 +1,40 @@
+package goobfuscated
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// MarshalGQL satisfies graphql.Marshaler of gqlgen, it writes the obfuscated
+// string. The interface has no error return, so an id which can not be
+// marshaled, see MarshalText, is written as null instead of panicking.
+func (id ID) MarshalGQL(w io.Writer) {
+	text, err := id.MarshalText()
+	if err != nil {
+		io.WriteString(w, "null")
+		return
+	}
+	io.WriteString(w, strconv.Quote(string(text)))
+}
+
+// UnmarshalGQL satisfies graphql.Unmarshaler of gqlgen. Like UnmarshalJSON it
+// accepts the obfuscated string as well as the obfuscated value as a number.
+func (id *ID) UnmarshalGQL(v any) (err error) {
+	switch v := v.(type) {
+	case string:
+		*id, err = ParseID(v)
+	case json.Number:
+		*id, err = parseNumber([]byte(v))
+	case int:
+		*id, err = parseNumber(strconv.AppendInt(nil, int64(v), 10))
+	case int64:
+		*id, err = parseNumber(strconv.AppendInt(nil, v, 10))
+	case float64:
+		*id, err = parseNumber(strconv.AppendFloat(nil, v, 'f', -1, 64))
+	default:
+		*id, err = 0, fmt.Errorf("can not unmarshal %T into id", v)
+	}
+	return err
+}
@@ -0,0 +1,263 @@
+// Package grpcid translates the obfuscated ids of gRPC messages, it is kept
+// apart from goobfuscated so that only its users depend on gRPC.
+package grpcid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/19byte/goobfuscated"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// StringSuffix is the suffix of the name of the string field paired with a
+// numeric id field by default, e.g. user_id_str holds the obfuscated id of
+// user_id, see WithStringField.
+const StringSuffix = "_str"
+
+// Option configures the interceptor returned by UnaryServerInterceptor.
+type Option func(*interceptor)
+
+// WithStringField configures how the string field holding the obfuscated id
+// of a selected numeric field is found. f returns nil if fd has no string
+// field, the interceptor then fails with codes.Internal. By default it is the
+// string field of the same message named after fd with StringSuffix.
+func WithStringField(f func(fd protoreflect.FieldDescriptor) protoreflect.FieldDescriptor) Option {
+	return func(i *interceptor) { i.pair = f }
+}
+
+// errNoStringField is the error of a selected field without a string field,
+// a bug of the server rather than of the request.
+var errNoStringField = errors.New("has no string field")
+
+type interceptor struct {
+	o    *goobfuscated.Obfuscator
+	sel  func(protoreflect.FieldDescriptor) bool
+	pair func(protoreflect.FieldDescriptor) protoreflect.FieldDescriptor
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor which translates the
+// numeric id fields selected by fieldSelector, singular or repeated and in
+// nested messages too. Each of them is paired with a string field holding the
+// obfuscated id, see WithStringField:
+//
+//	message GetUserRequest {
+//	  uint64 user_id = 1;     // seen by the handler.
+//	  string user_id_str = 2; // sent by the client.
+//	}
+//
+// The obfuscated ids of the string fields of the request are parsed into the
+// numeric fields before the handler is called, and the string fields are
+// cleared. A numeric field sent by the client is overwritten, it can not
+// bypass the obfuscation. The numeric fields of the response are formatted
+// into the string fields and cleared, the raw ids do not leave the server.
+// An empty string field of the request, or an unset numeric field of the
+// response, leaves the other one unset.
+//
+// The fields of kind uint64, int64, uint32 and int32 and their fixed and
+// zigzag variants are numeric fields, the others are not translated even if
+// selected. A request with an invalid obfuscated id, see StrictParseID, or an
+// id which does not fit the numeric field fails with codes.InvalidArgument, a
+// response with an id out of the range of o with codes.Internal.
+func UnaryServerInterceptor(o *goobfuscated.Obfuscator, fieldSelector func(protoreflect.FieldDescriptor) bool, opts ...Option) grpc.UnaryServerInterceptor {
+	i := &interceptor{o: o, sel: fieldSelector, pair: stringField}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if m, ok := req.(proto.Message); ok {
+			if err := i.translate(m.ProtoReflect(), i.parse); err != nil {
+				code := codes.InvalidArgument
+				if errors.Is(err, errNoStringField) {
+					code = codes.Internal
+				}
+				return nil, status.Error(code, err.Error())
+			}
+		}
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		if m, ok := resp.(proto.Message); ok {
+			if err := i.translate(m.ProtoReflect(), i.format); err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+		}
+		return resp, nil
+	}
+}
+
+// stringField returns the string field of the message of fd named after fd
+// with StringSuffix, or nil.
+func stringField(fd protoreflect.FieldDescriptor) protoreflect.FieldDescriptor {
+	sd := fd.ContainingMessage().Fields().ByName(fd.Name() + StringSuffix)
+	if sd == nil || sd.Kind() != protoreflect.StringKind || sd.IsList() != fd.IsList() {
+		return nil
+	}
+	return sd
+}
+
+// numeric reports whether fd is a numeric field which may hold an id.
+func numeric(fd protoreflect.FieldDescriptor) bool {
+	switch fd.Kind() {
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return !fd.IsMap()
+	}
+	return false
+}
+
+// translate walks m and its nested messages and calls f with every selected
+// numeric field and its string field. The fields of m are iterated by their
+// descriptors, f may set and clear them.
+func (i *interceptor) translate(m protoreflect.Message, f func(m protoreflect.Message, nd, sd protoreflect.FieldDescriptor) error) error {
+	if !m.IsValid() {
+		return nil
+	}
+	var nested []protoreflect.Message
+	fields := m.Descriptor().Fields()
+	for k := 0; k < fields.Len(); k++ {
+		fd := fields.Get(k)
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil && m.Has(fd) {
+				m.Get(fd).Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+					nested = append(nested, v.Message())
+					return true
+				})
+			}
+		case fd.Message() != nil && fd.IsList():
+			if m.Has(fd) {
+				for j, l := 0, m.Get(fd).List(); j < l.Len(); j++ {
+					nested = append(nested, l.Get(j).Message())
+				}
+			}
+		case fd.Message() != nil:
+			if m.Has(fd) {
+				nested = append(nested, m.Get(fd).Message())
+			}
+		case numeric(fd) && i.sel(fd):
+			sd := i.pair(fd)
+			if sd == nil {
+				return fmt.Errorf("field %s %w", fd.FullName(), errNoStringField)
+			}
+			if err := f(m, fd, sd); err != nil {
+				return err
+			}
+		}
+	}
+	for _, n := range nested {
+		if err := i.translate(n, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parse sets the numeric field nd of m to the ids of the string field sd, and
+// clears sd.
+func (i *interceptor) parse(m protoreflect.Message, nd, sd protoreflect.FieldDescriptor) error {
+	m.Clear(nd)
+	if !m.Has(sd) {
+		return nil
+	}
+	if !nd.IsList() {
+		v, err := i.parseValue(nd, m.Get(sd).String())
+		if err != nil {
+			return err
+		}
+		m.Set(nd, v)
+		m.Clear(sd)
+		return nil
+	}
+	ss, ns := m.Get(sd).List(), m.Mutable(nd).List()
+	for j := 0; j < ss.Len(); j++ {
+		v, err := i.parseValue(nd, ss.Get(j).String())
+		if err != nil {
+			return err
+		}
+		ns.Append(v)
+	}
+	m.Clear(sd)
+	return nil
+}
+
+// parseValue returns the value of the field fd holding the id of s.
+func (i *interceptor) parseValue(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	id, err := i.o.StrictParseID(s)
+	if err != nil {
+		return protoreflect.Value{}, fmt.Errorf("field %s: %w", fd.FullName(), err)
+	}
+	n := id.Uint64()
+	switch fd.Kind() {
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(n), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if n <= math.MaxUint32 {
+			return protoreflect.ValueOfUint32(uint32(n)), nil
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if n <= math.MaxInt64 {
+			return protoreflect.ValueOfInt64(int64(n)), nil
+		}
+	default:
+		if n <= math.MaxInt32 {
+			return protoreflect.ValueOfInt32(int32(n)), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("field %s: id %d overflows its %s: %w", fd.FullName(), n, fd.Kind(), goobfuscated.ErrOutOfRange)
+}
+
+// format sets the string field sd of m to the obfuscated ids of the numeric
+// field nd, and clears nd.
+func (i *interceptor) format(m protoreflect.Message, nd, sd protoreflect.FieldDescriptor) error {
+	if !m.Has(nd) {
+		return nil
+	}
+	if !nd.IsList() {
+		s, err := i.formatValue(nd, m.Get(nd))
+		if err != nil {
+			return err
+		}
+		m.Set(sd, protoreflect.ValueOfString(s))
+		m.Clear(nd)
+		return nil
+	}
+	m.Clear(sd)
+	ns, ss := m.Get(nd).List(), m.Mutable(sd).List()
+	for j := 0; j < ns.Len(); j++ {
+		s, err := i.formatValue(nd, ns.Get(j))
+		if err != nil {
+			return err
+		}
+		ss.Append(protoreflect.ValueOfString(s))
+	}
+	m.Clear(nd)
+	return nil
+}
+
+// formatValue returns the obfuscated id held by the value v of the field fd.
+func (i *interceptor) formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) (string, error) {
+	var n uint64
+	switch fd.Kind() {
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind, protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n = v.Uint()
+	default:
+		if v.Int() < 0 {
+			return "", fmt.Errorf("field %s: negative id %d: %w", fd.FullName(), v.Int(), goobfuscated.ErrOutOfRange)
+		}
+		n = uint64(v.Int())
+	}
+	if _, err := i.o.ObfuscateChecked(n); err != nil {
+		return "", fmt.Errorf("field %s: %w", fd.FullName(), err)
+	}
+	return i.o.FormatID(goobfuscated.ID(n)), nil
+}
@@ -0,0 +1,235 @@
+package grpcid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/19byte/goobfuscated"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// testFile declares the messages of the tests:
+//
+//	message Item {
+//	  uint64 id = 1;
+//	  string id_str = 2;
+//	}
+//	message Request {
+//	  int64 user_id = 1;
+//	  string user_id_str = 2;
+//	  repeated uint64 ids = 3;
+//	  repeated string ids_str = 4;
+//	  Item item = 5;
+//	  repeated Item items = 6;
+//	  uint32 page = 7;
+//	}
+var testFile = func() protoreflect.FileDescriptor {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, repeated bool, msg string) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    label.Enum(),
+			Type:     typ.Enum(),
+		}
+		if msg != "" {
+			fd.TypeName = proto.String(msg)
+		}
+		return fd
+	}
+	const (
+		u64     = descriptorpb.FieldDescriptorProto_TYPE_UINT64
+		i64     = descriptorpb.FieldDescriptorProto_TYPE_INT64
+		u32     = descriptorpb.FieldDescriptorProto_TYPE_UINT32
+		str     = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		message = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("grpcid_test.proto"),
+		Package: proto.String("grpcid.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Item"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, u64, false, ""),
+				field("id_str", 2, str, false, ""),
+			},
+		}, {
+			Name: proto.String("Request"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("user_id", 1, i64, false, ""),
+				field("user_id_str", 2, str, false, ""),
+				field("ids", 3, u64, true, ""),
+				field("ids_str", 4, str, true, ""),
+				field("item", 5, message, false, ".grpcid.test.Item"),
+				field("items", 6, message, true, ".grpcid.test.Item"),
+				field("page", 7, u32, false, ""),
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		panic(err)
+	}
+	return fd
+}()
+
+func newMessage(name protoreflect.Name) *dynamicpb.Message {
+	return dynamicpb.NewMessage(testFile.Messages().ByName(name))
+}
+
+// selectIDs selects the id fields, not the page.
+func selectIDs(fd protoreflect.FieldDescriptor) bool {
+	return fd.Name() == "ids" || strings.HasSuffix(string(fd.Name()), "id")
+}
+
+func newTestObfuscator(t *testing.T) *goobfuscated.Obfuscator {
+	t.Helper()
+	o, err := goobfuscated.NewObfuscator(goobfuscated.WithSeed(20200101))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func get(m protoreflect.Message, name protoreflect.Name) protoreflect.Value {
+	return m.Get(m.Descriptor().Fields().ByName(name))
+}
+
+func set(m protoreflect.Message, name protoreflect.Name, v protoreflect.Value) {
+	m.Set(m.Descriptor().Fields().ByName(name), v)
+}
+
+func has(m protoreflect.Message, name protoreflect.Name) bool {
+	return m.Has(m.Descriptor().Fields().ByName(name))
+}
+
+func appendTo(m protoreflect.Message, name protoreflect.Name, vs ...protoreflect.Value) {
+	l := m.Mutable(m.Descriptor().Fields().ByName(name)).List()
+	for _, v := range vs {
+		l.Append(v)
+	}
+}
+
+func TestInbound(t *testing.T) {
+	o := newTestObfuscator(t)
+	req := newMessage("Request")
+	set(req, "user_id_str", protoreflect.ValueOfString(o.FormatID(7)))
+	set(req, "user_id", protoreflect.ValueOfInt64(99)) // sent by the client, overwritten.
+	appendTo(req, "ids_str", protoreflect.ValueOfString(o.FormatID(1)), protoreflect.ValueOfString(o.FormatID(2)))
+	item := newMessage("Item")
+	set(item, "id_str", protoreflect.ValueOfString(o.FormatID(3)))
+	set(req, "item", protoreflect.ValueOfMessage(item))
+	elem := newMessage("Item")
+	set(elem, "id_str", protoreflect.ValueOfString(o.FormatID(4)))
+	appendTo(req, "items", protoreflect.ValueOfMessage(elem))
+	set(req, "page", protoreflect.ValueOfUint32(2))
+
+	handler := func(ctx context.Context, r any) (any, error) {
+		m := r.(proto.Message).ProtoReflect()
+		if got := get(m, "user_id").Int(); got != 7 {
+			t.Errorf("user_id = %d, want 7", got)
+		}
+		if has(m, "user_id_str") || has(m, "ids_str") {
+			t.Error("the string fields are not cleared")
+		}
+		if l := get(m, "ids").List(); l.Len() != 2 || l.Get(0).Uint() != 1 || l.Get(1).Uint() != 2 {
+			t.Errorf("ids = %v, want [1 2]", l)
+		}
+		if got := get(get(m, "item").Message(), "id").Uint(); got != 3 {
+			t.Errorf("item.id = %d, want 3", got)
+		}
+		if got := get(get(m, "items").List().Get(0).Message(), "id").Uint(); got != 4 {
+			t.Errorf("items[0].id = %d, want 4", got)
+		}
+		if got := get(m, "page").Uint(); got != 2 {
+			t.Errorf("page = %d, want 2", got)
+		}
+		return nil, nil
+	}
+	if _, err := UnaryServerInterceptor(o, selectIDs)(context.Background(), req, &grpc.UnaryServerInfo{}, handler); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInboundInvalid(t *testing.T) {
+	o := newTestObfuscator(t)
+	req := newMessage("Request")
+	set(req, "user_id_str", protoreflect.ValueOfString("not an id"))
+	handler := func(ctx context.Context, r any) (any, error) {
+		t.Error("the handler is called")
+		return nil, nil
+	}
+	_, err := UnaryServerInterceptor(o, selectIDs)(context.Background(), req, &grpc.UnaryServerInfo{}, handler)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("interceptor returns %v, want InvalidArgument", err)
+	}
+}
+
+func TestOutbound(t *testing.T) {
+	o := newTestObfuscator(t)
+	resp := newMessage("Request")
+	set(resp, "user_id", protoreflect.ValueOfInt64(7))
+	appendTo(resp, "ids", protoreflect.ValueOfUint64(1), protoreflect.ValueOfUint64(2))
+	item := newMessage("Item")
+	set(item, "id", protoreflect.ValueOfUint64(3))
+	set(resp, "item", protoreflect.ValueOfMessage(item))
+	set(resp, "page", protoreflect.ValueOfUint32(2))
+
+	handler := func(ctx context.Context, r any) (any, error) { return resp, nil }
+	out, err := UnaryServerInterceptor(o, selectIDs)(context.Background(), newMessage("Request"), &grpc.UnaryServerInfo{}, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := out.(proto.Message).ProtoReflect()
+	if got, want := get(m, "user_id_str").String(), o.FormatID(7); got != want {
+		t.Errorf("user_id_str = %q, want %q", got, want)
+	}
+	if has(m, "user_id") || has(m, "ids") {
+		t.Error("the raw ids are not cleared")
+	}
+	if l := get(m, "ids_str").List(); l.Len() != 2 || l.Get(0).String() != o.FormatID(1) || l.Get(1).String() != o.FormatID(2) {
+		t.Errorf("ids_str = %v", l)
+	}
+	if got, want := get(get(m, "item").Message(), "id_str").String(), o.FormatID(3); got != want {
+		t.Errorf("item.id_str = %q, want %q", got, want)
+	}
+	if got := get(m, "page").Uint(); got != 2 {
+		t.Errorf("page = %d, want 2", got)
+	}
+}
+
+func TestOutboundOutOfRange(t *testing.T) {
+	o := newTestObfuscator(t)
+	resp := newMessage("Request")
+	set(resp, "user_id", protoreflect.ValueOfInt64(-1))
+	handler := func(ctx context.Context, r any) (any, error) { return resp, nil }
+	_, err := UnaryServerInterceptor(o, selectIDs)(context.Background(), newMessage("Request"), &grpc.UnaryServerInfo{}, handler)
+	if status.Code(err) != codes.Internal {
+		t.Errorf("interceptor returns %v, want Internal", err)
+	}
+}
+
+func TestNoStringField(t *testing.T) {
+	o := newTestObfuscator(t)
+	all := func(protoreflect.FieldDescriptor) bool { return true }
+	handler := func(ctx context.Context, r any) (any, error) { return nil, nil }
+	req := newMessage("Request")
+	set(req, "page", protoreflect.ValueOfUint32(2))
+	_, err := UnaryServerInterceptor(o, all)(context.Background(), req, &grpc.UnaryServerInfo{}, handler)
+	if status.Code(err) != codes.Internal || !strings.Contains(err.Error(), "page") {
+		t.Errorf("interceptor returns %v, want an Internal error naming page", err)
+	}
+}
@@ -1,16 +1,15 @@
 package goobfuscated
 
 import (
-	crand "crypto/rand"
+	"bytes"
+	"cmp"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"math"
-	"math/big"
-	"math/rand"
-	"time"
+	"strconv"
 )
 
 type ID uint64
@@ -20,7 +19,14 @@ const (
 	// It should be 2^N.
 	// It is set by default to the upper bound of an (2^53 - 1)
 	// which represents the maximum safe integer(Number.MAX_SAFE_INTEGER) in JavaScript.
-	MaxInt = 1<<53 - 1 // 2,147,483,647
+	// It is the bound of the default width only: the package level functions
+	// use the bound of the default Obfuscator, installed by SetDefault.
+	MaxInt = 1<<53 - 1 // 9,007,199,254,740,991
+
+	// MinBits & MaxBits are the bounds of the width accepted by WithBits.
+	// The width of the default scheme is 53 which matches MaxInt.
+	MinBits = 8
+	MaxBits = 64
 
 	// MillerRabin is used to configure the ProbablyPrime function
 	// which is used to verify prime numbers.
@@ -29,81 +35,75 @@ const (
 )
 
 var (
-	// id obfuscate prime number.
-	defaultIDPrime      uint64
-	defaultIDModInverse uint64
-	defaultIDRandom     uint64
-
 	// urlEncoding & urlEncoding is alias for base64.RawURLEncoding and
 	// binary.LittleEndian for brevity and consistency in encoding and decoding.
 	urlEncoding  = base64.RawURLEncoding
 	littleEndian = binary.LittleEndian
 )
 
-// primes for obfuscating the id number.
-// Downloaded from: http://primes.utm.edu/lists/small/millions/
-var primes = []uint64{
-	452977333, 452977381, 452977403, 452977411, 452977429, 452977453, 452977463, 452977507,
-	452977517, 452977519, 452977573, 452977583, 452977589, 452977601, 452977607, 452977621,
-	452977649, 452977703, 452977711, 452977739, 452977747, 452977769, 452977771, 452977783,
-	452977807, 452977843, 452977849, 452977853, 452977873, 452977891, 452977913, 452977927,
-	452977969, 452977991, 452977997, 452978003, 452978011, 452978017, 452978023, 452978027,
-	452978047, 452978051, 452978059, 452978063, 452978081, 452978137, 452978153, 452978171,
-	452978249, 452978257, 452978299, 452978321, 452978381, 452978389, 452978419, 452978429,
-	452978431, 452978443, 452978453, 452978473, 452978483, 452978503, 452978507, 452978557,
-	452978567, 452978587, 452978593, 452978599, 452978609, 452978611, 452978623, 452978627,
-	452978633, 452978663, 452978671, 452978683, 452978699, 452978707, 452978723, 452978737,
-	452978749, 452978777, 452978803, 452978833, 452978837, 452978849, 452978861, 452978881,
-	452978909, 452978941, 452978987, 452978989, 452979017, 452979031, 452979143, 452979173,
-	452979187, 452979199, 452979203, 452979251, 452979281, 452979301, 452979311, 452979341,
-	452979343, 452979349, 452979353, 452979377, 452979407, 452979463, 452979479, 452979491,
-	452979509, 452979511, 452979517, 452979523, 452979539, 452979577, 452979599, 452979617,
-	452979661, 452979689, 452979691, 452979701, 452979713, 452979731, 452979743, 452979763,
-	452979811, 452979827, 452979851, 452979853, 452979859, 452979881, 452979907, 452979941,
-	452979959, 452979979, 452980001, 452980019, 452980037, 452980043, 452980057, 452980061,
-	452980063, 452980093, 452980117, 452980127, 452980133, 452980163, 452980201, 452980211,
-	452980243, 452980267, 452980279, 452980291, 452980309, 452980321, 452980343, 452980357,
-	452980369, 452980373, 452980379, 452980399, 452980471, 452980483, 452980511, 452980523,
-	452980531, 452980537, 452980547, 452980553, 452980597, 452980601, 452980613, 452980621,
-	452980631, 452980643, 452980681, 452980687, 452980709, 452980721, 452980777, 452980789,
-	452980831, 452980921, 452980939, 452980961, 452980967, 452980981, 452980991, 452980993,
-	452980999, 452981017, 452981027, 452981029, 452981041, 452981051, 452981069, 452981077,
-	452981117, 452981119, 452981129, 452981149, 452981197, 452981203, 452981213, 452981281,
-	452981327, 452981357, 452981369, 452981411, 452981447, 452981453, 452981467, 452981483,
-	452981519, 452981531, 452981569, 452981587, 452981597, 452981623, 452981647, 452981657,
-	452981663, 452981671, 452981689, 452981693, 452981699, 452981731, 452981741, 452981747,
-	452981761, 452981777, 452981783, 452981797, 452981801, 452981819, 452981821, 452981833,
-}
-
-func init() {
-	// Create a new random number generator with a unique seed.
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-
-	// Random a PRIME number from local primes. It must be smaller
-	// than 2147483647 (MAX ID).
-	defaultIDPrime = primes[rng.Intn(len(primes))]
-
-	// defaultIDPrime must be a valid prime.
-	if !big.NewInt(int64(defaultIDPrime)).ProbablyPrime(MillerRabin) {
-		accuracy := 1.0 - 1.0/math.Pow(float64(4), float64(MillerRabin))
-		panic(fmt.Errorf("prime is not a valid prime. [Accuracy: %f]", accuracy))
-	}
-
-	// Calculate the Mod Inverse of the Prime number such that
-	// (PRIME * INVERSE) & MAX ID == 1.
-	defaultIDModInverse = modInverse(int64(defaultIDPrime))
-	// Generate a Pure Random Integer less than 2147483647 (MAX ID).
-	defaultIDRandom = randN(int64(MaxInt) - 1)
+// NewID returns n as an ID, or an error if n is out of the id space of the
+// default Obfuscator, greater than MaxInt unless configured. Converting with
+// ID(n) is still possible when n is known to be in range.
+func NewID(n uint64) (ID, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	if limit := o.limit(); n > limit {
+		return 0, newError(ErrOutOfRange, "id %d is out of range [0,%d]", n, limit)
+	}
+	return ID(n), nil
 }
 
+// MustNewID is like NewID, but it panics if n is out of range.
+func MustNewID(n uint64) ID {
+	id, err := NewID(n)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
 
 // MarshalJSON satisfies json.Marshaller and transparently obfuscates the value
-// using Default prime
-func (id *ID) MarshalJSON() ([]byte, error) { return json.Marshal(id.String()) }
+// using Default prime. The id is emitted as the obfuscated string, or as a
+// JSON number if the default Obfuscator is configured WithJSONNumber. It has a
+// value receiver, so ids held by value, e.g. in map values or in structs
+// marshaled by value, are emitted like the addressable ones.
+func (id ID) MarshalJSON() ([]byte, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return nil, err
+	}
+	return o.appendJSON(nil, id.Uint64())
+}
+
+// AppendJSON appends the JSON form of id, as emitted by MarshalJSON, to dst
+// and returns the extended buffer, e.g. for hand-written encoders. The
+// string is quoted in place, without allocating. Like MarshalJSON, it
+// returns an error matching ErrOutOfRange for an id out of the range of the
+// default Obfuscator, or the error of its creation, and dst unchanged.
+func (id ID) AppendJSON(dst []byte) ([]byte, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return dst, err
+	}
+	return o.appendJSON(dst, id.Uint64())
+}
 
 // UnmarshalJSON satisfies json.Marshaller and transparently deobfuscates the
-// value using inverse of Default prime
+// value using inverse of Default prime. Besides the obfuscated string it
+// accepts a bare JSON number, which is taken as the obfuscated value returned
+// by Encode. A JSON null resets the id to zero, so nullable id fields decode
+// into the zero ID, see IsZero.
 func (id *ID) UnmarshalJSON(b []byte) (err error) {
+	if b = bytes.TrimSpace(b); string(b) == "null" {
+		*id = 0
+		return nil
+	}
+	if len(b) > 0 && b[0] != '"' {
+		*id, err = parseNumber(b)
+		return err
+	}
 	var s string
 	// json.Unmarshal converts a quoted JSON bytes string literal data into an
 	// actual string s. The rules are different than for Go, so cannot
@@ -117,29 +117,138 @@ func (id *ID) UnmarshalJSON(b []byte) (err error) {
 	return err
 }
 
-// String returns the obfuscated id in base64 string format and with
-// little-endian byte order.
-func (id *ID) String() string {
-	buf := make([]byte, 8)
-	littleEndian.PutUint64(buf, id.obfuscate())
-	return urlEncoding.EncodeToString(buf)
+// parseNumber deobfuscates the JSON number b. Numbers which are not integral
+// or not obfuscated values of the default Obfuscator are rejected.
+func parseNumber(b []byte) (ID, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		// Integral numbers may still be written with a fraction or an
+		// exponent, e.g. 1.0 or 1e3.
+		f, ferr := strconv.ParseFloat(string(b), 64)
+		if ferr != nil || f < 0 || f != math.Trunc(f) || f >= math.MaxUint64 {
+			return 0, newError(ErrInvalidFormat, "fails to decode id: invalid number %s", b)
+		}
+		n = uint64(f)
+	}
+	id, ok := o.deObfuscate(n)
+	if !ok {
+		return 0, newError(ErrOutOfRange, "fails to decode id: %d is out of range", n)
+	}
+	return ID(id), nil
+}
+
+// MarshalText satisfies encoding.TextMarshaler, it returns the same
+// obfuscated string as String, so ids used as JSON map keys or by text based
+// formats (YAML, TOML, flags) are obfuscated too.
+func (id ID) MarshalText() ([]byte, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return nil, err
+	}
+	return o.appendID(nil, id.Uint64())
+}
+
+// UnmarshalText satisfies encoding.TextUnmarshaler and is the inverse of
+// MarshalText, the id is reset to zero if text can not be parsed.
+func (id *ID) UnmarshalText(text []byte) (err error) {
+	*id, err = ParseID(string(text))
+	return err
+}
+
+// MarshalBinary satisfies encoding.BinaryMarshaler, it returns exactly the
+// 8 bytes of the obfuscated id without the base64 layer of String. The bytes
+// are laid out in the byte order of the default Obfuscator, little-endian
+// unless configured. The msgpackid package encodes ids in those 8 bytes with
+// github.com/vmihailenco/msgpack/v5.
+func (id ID) MarshalBinary() ([]byte, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return nil, err
+	}
+	n, err := o.ObfuscateChecked(id.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	return o.binary(n), nil
+}
+
+// UnmarshalBinary satisfies encoding.BinaryUnmarshaler and is the inverse of
+// MarshalBinary, it returns an error if data is not exactly 8 bytes.
+func (id *ID) UnmarshalBinary(data []byte) error {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return err
+	}
+	*id, err = o.parseBinary(data)
+	return err
+}
+
+// String returns the obfuscated id in base64 string format and with the
+// byte order of the default Obfuscator, little-endian unless configured.
+// It has a value receiver, so an ID value passed to fmt is obfuscated too.
+// It panics if the default Obfuscator could not be created, use FormatID of
+// Default, or MarshalText, to get the error instead.
+func (id ID) String() string { return mustDefault().FormatID(id) }
+
+// Format satisfies fmt.Formatter: the %s, %v and %q verbs print the obfuscated
+// string returned by String, %v included so that logs do not leak raw ids.
+// The integer verbs, e.g. %d or %x, print the raw value.
+func (id ID) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 's', 'v', 'q':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), id.String())
+	default:
+		fmt.Fprintf(f, fmt.FormatString(f, verb), uint64(id))
+	}
+}
+
+// AppendString appends the obfuscated string of id, as returned by
+// ID.String(), to dst and returns the extended buffer.
+// It uses the default Obfuscator and panics if it could not be created.
+func AppendString(dst []byte, id ID) []byte { return mustDefault().AppendString(dst, id) }
+
+// WriteString writes the obfuscated string of id, as returned by ID.String(),
+// to w. It uses the default Obfuscator and returns the error of its creation
+// if it could not be created.
+func WriteString(w io.Writer, id ID) (int, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	return o.WriteString(w, id)
 }
 
 // ParseID is an inverse operation of ID.String(), returns zero if
 // any error occurs during parsing.
 func ParseID(s string) (ID, error) {
-	switch buf, err := urlEncoding.DecodeString(s); {
-	case err != nil:
-		return 0, fmt.Errorf("fails to decode id: %w", err)
-	case len(buf) != 8: // ID expected to be exactly 8 bytes.
-		return 0, errors.New("unexpected id format")
-	default:
-		return ID(DeObfuscate(littleEndian.Uint64(buf))), nil
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
 	}
+	return o.ParseID(s)
+}
+
+// ParseValue is an inverse operation of ID.Encode(), it deobfuscates the
+// obfuscated value n into an ID like ParseID does for ID.String(). It panics
+// if the default Obfuscator could not be created.
+func ParseValue(n uint64) ID { return ID(DeObfuscate(n)) }
+
+// StrictParseID is like ParseID, but it returns an error if the decoded id
+// does not re-obfuscate to the value held by s, see Obfuscator.StrictParseID.
+func StrictParseID(s string) (ID, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	return o.StrictParseID(s)
 }
 
 // obfuscate is used to encode n using Knuth's hashing algorithm.
-func (id *ID) obfuscate() uint64 { return Obfuscate(id.Value()) }
+func (id *ID) obfuscate() uint64 { return Obfuscate(id.Uint64()) }
 
 // deObfuscate is used to decode n back to the original.
 // It will only decode correctly if the prime selectors is consistent
@@ -147,40 +256,64 @@ func (id *ID) obfuscate() uint64 { return Obfuscate(id.Value()) }
 func (id *ID) deObfuscate(n uint64) { *id = ID(DeObfuscate(n)) }
 
 // Encode & Decode obfuscate and deObfuscate the ID.
+// They panic if the default Obfuscator could not be created.
 func (id *ID) Encode() uint64  { return id.obfuscate() }
 func (id *ID) Decode(n uint64) { id.deObfuscate(n) }
 
-// Value returns the raw integer value.
-func (id *ID) Value() uint64 { return uint64(*id) }
+// EncodeAll returns both the obfuscated value of id, as returned by Encode,
+// and its string form, as returned by String, e.g. for a cache key and the
+// response. id is obfuscated once, where calling Encode and String obfuscates
+// it twice, and both forms hold the same nonce, see WithNonce. It panics if
+// the default Obfuscator could not be created.
+func (id ID) EncodeAll() (n uint64, s string) {
+	o := mustDefault()
+	n = o.Obfuscate(id.Uint64())
+	if id == 0 && o.zeroSet {
+		return n, o.zeroString
+	}
+	return n, o.format(n)
+}
+
+// Uint64 returns the raw integer value. Value is taken by driver.Valuer.
+func (id *ID) Uint64() uint64 { return uint64(*id) }
+
+// Equal reports whether id and other hold the same raw value.
+func (id ID) Equal(other ID) bool { return id == other }
+
+// Compare returns -1, 0 or +1 depending on whether the raw value of id is less
+// than, equal to or greater than the one of other. The obfuscated values are
+// not ordered, ids sort by their real numeric value.
+func (id ID) Compare(other ID) int { return cmp.Compare(id, other) }
 
 // IsZero reports if the id is the zero value.
 func (id *ID) IsZero() bool { return *id == 0 }
 
 // Obfuscate is used to encode id using Knuth's hashing algorithm.
-func Obfuscate(id uint64) uint64 { return ((id * defaultIDPrime) & MaxInt) ^ defaultIDRandom }
+// It uses the default Obfuscator and panics if it could not be created, the
+// width of the id space is the one of the default, see SetDefault.
+func Obfuscate(id uint64) uint64 { return mustDefault().Obfuscate(id) }
+
+// ObfuscateChecked is like Obfuscate, but it returns an error if id is
+// greater than the upper bound of the default Obfuscator, MaxInt unless
+// configured, instead of silently wrapping it.
+func ObfuscateChecked(id uint64) (uint64, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	return o.ObfuscateChecked(id)
+}
 
 // DeObfuscate is used to decode n back to the original id.
 // It will only decode correctly if the prime selectors is consistent
-// with what was used to encode n.
-func DeObfuscate(n uint64) uint64 { return ((n ^ defaultIDRandom) * defaultIDModInverse) & MaxInt }
-
-// modInverse returns the modular inverse of a given prime number.
-// The modular inverse is defined such that
-// (PRIME * MODULAR_INVERSE) & (MAX_INT_VALUE) = 1.
-//
-// See: http://en.wikipedia.org/wiki/Modular_multiplicative_inverse
-//
-// NOTE: prime is assumed to be a valid prime. If prime is outside the bounds of
-// an int64, then the function panics as it can not calculate the mod inverse.
-func modInverse(prime int64) uint64 {
-	max := big.NewInt(MaxInt + 1)
-	return (&big.Int{}).ModInverse(big.NewInt(prime), max).Uint64()
-}
-
-// randN returns a cryptographically secure random number
-// in the range [1,N].
-func randN(N int64) uint64 {
-	n, _ := crand.Int(crand.Reader, big.NewInt(N))
-	in := n.Uint64() + 1
-	return in
-}
+// with what was used to encode n. It uses the default Obfuscator and panics
+// if it could not be created.
+func DeObfuscate(n uint64) uint64 { return mustDefault().DeObfuscate(n) }
+
+// ObfuscateSlice returns a new slice holding the obfuscated ids.
+// It uses the default Obfuscator and panics if it could not be created.
+func ObfuscateSlice(ids []uint64) []uint64 { return mustDefault().ObfuscateSlice(ids) }
+
+// DeObfuscateSlice is an inverse operation of ObfuscateSlice.
+// It uses the default Obfuscator and panics if it could not be created.
+func DeObfuscateSlice(ns []uint64) []uint64 { return mustDefault().DeObfuscateSlice(ns) }
@@ -0,0 +1,221 @@
+package goobfuscated
+
+import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+)
+
+// ID128 is a 128 bits wide id, e.g. a UUID, Hi holds the most significant
+// 64 bits. It is obfuscated the same way as ID, over the ring of 1<<128
+// with a 128 bits prime.
+type ID128 struct{ Hi, Lo uint64 }
+
+// Obfuscator128 is the 128 bits counterpart of Obfuscator, it holds a 128
+// bits prime, its mod inverse and the xor mask. The functions and the methods
+// of ID128 use the default one, see SetDefault128.
+type Obfuscator128 struct {
+	prime, modInverse, random ID128
+}
+
+var (
+	// default128 is the Obfuscator128 used by ID128, it is initialized on
+	// first use unless installed by SetDefault128. Like the default
+	// Obfuscator the initialized one is chosen at random and only lives as
+	// long as the process does.
+	default128     atomic.Pointer[Obfuscator128]
+	default128Err  error
+	default128Once sync.Once
+)
+
+// NewObfuscator128 returns the 128 bits scheme derived from seed: the prime
+// and the mask are drawn from a ChaCha8 generator keyed by the SHA-256 of
+// seed, so the same seed gives the same scheme on every run and on every
+// machine, like WithSeed does for an Obfuscator. The seed is the secret of the
+// scheme, e.g. a random string read from the environment. It returns an error
+// if seed is empty.
+func NewObfuscator128(seed []byte) (*Obfuscator128, error) {
+	if len(seed) == 0 {
+		return nil, errors.New("seed must not be empty")
+	}
+	return newObfuscator128(sha256.Sum256(seed)), nil
+}
+
+// newObfuscator128 returns the 128 bits scheme drawn from a ChaCha8 generator
+// keyed by key.
+func newObfuscator128(key [32]byte) *Obfuscator128 {
+	rng := rand.NewChaCha8(key)
+
+	// The prime is the first one following a random odd point of the upper
+	// half of the 127 bits space. A 127 bits prime is odd and thus invertible
+	// modulo 1<<128.
+	p := id128ToBig(ID128{Hi: rng.Uint64()>>2 | 1<<62, Lo: rng.Uint64() | 1})
+	for two := big.NewInt(2); !p.ProbablyPrime(MillerRabin); {
+		p.Add(p, two)
+	}
+	max := (&big.Int{}).Lsh(big.NewInt(1), 128)
+	inv := (&big.Int{}).ModInverse(p, max) // p is odd.
+	return &Obfuscator128{
+		prime:      id128FromBig(p),
+		modInverse: id128FromBig(inv),
+		random:     ID128{Hi: rng.Uint64(), Lo: rng.Uint64()},
+	}
+}
+
+// defaultObfuscator128 returns the default 128 bits scheme, creating it on
+// first use.
+func defaultObfuscator128() (*Obfuscator128, error) {
+	if o := default128.Load(); o != nil {
+		return o, nil
+	}
+	default128Once.Do(func() {
+		var key [32]byte
+		if _, err := io.ReadFull(crand.Reader, key[:]); err != nil {
+			default128Err = fmt.Errorf("fails to generate 128 bits scheme: %w", err)
+			return
+		}
+		default128.CompareAndSwap(nil, newObfuscator128(key))
+	})
+	if o := default128.Load(); o != nil {
+		return o, nil
+	}
+	return nil, default128Err
+}
+
+// mustDefault128 is like defaultObfuscator128 but panics on error.
+func mustDefault128() *Obfuscator128 {
+	o, err := defaultObfuscator128()
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+// SetDefault128 installs o as the Obfuscator128 used by ID128 and the 128 bits
+// functions, e.g. a seeded one so that the stored ID128 strings keep decoding
+// after a restart. Like SetDefault, configure it once at startup. o must not
+// be nil.
+func SetDefault128(o *Obfuscator128) {
+	if o != nil {
+		default128.Store(o)
+	}
+}
+
+// Obfuscate returns the obfuscated value of id.
+func (o *Obfuscator128) Obfuscate(id ID128) ID128 { return mul128(id, o.prime).xor(o.random) }
+
+// DeObfuscate is an inverse operation of Obfuscate.
+func (o *Obfuscator128) DeObfuscate(n ID128) ID128 { return mul128(n.xor(o.random), o.modInverse) }
+
+// FormatID returns the obfuscated id in base64 string format, the 16 bytes
+// are laid out in little-endian byte order like the 8 bytes of ID.String().
+func (o *Obfuscator128) FormatID(id ID128) string {
+	n := o.Obfuscate(id)
+	buf := make([]byte, 16)
+	littleEndian.PutUint64(buf[:8], n.Lo)
+	littleEndian.PutUint64(buf[8:], n.Hi)
+	return urlEncoding.EncodeToString(buf)
+}
+
+// ParseID is an inverse operation of FormatID.
+func (o *Obfuscator128) ParseID(s string) (ID128, error) {
+	switch buf, err := urlEncoding.DecodeString(s); {
+	case err != nil:
+		return ID128{}, newError(ErrInvalidFormat, "fails to decode id: %w", err)
+	case len(buf) != 16: // ID128 expected to be exactly 16 bytes.
+		return ID128{}, newError(ErrInvalidLength, "unexpected id format")
+	default:
+		n := ID128{Hi: littleEndian.Uint64(buf[8:]), Lo: littleEndian.Uint64(buf[:8])}
+		return o.DeObfuscate(n), nil
+	}
+}
+
+// Obfuscate128 is the 128 bits counterpart of Obfuscate, with the default
+// Obfuscator128. It panics if the default could not be created.
+func Obfuscate128(id ID128) ID128 { return mustDefault128().Obfuscate(id) }
+
+// DeObfuscate128 is an inverse operation of Obfuscate128.
+// It panics if the default 128 bits scheme could not be created.
+func DeObfuscate128(n ID128) ID128 { return mustDefault128().DeObfuscate(n) }
+
+// ID128FromBytes returns the id held by b in big-endian byte order, which is
+// the byte order of a UUID.
+func ID128FromBytes(b [16]byte) ID128 {
+	return ID128{Hi: binary.BigEndian.Uint64(b[:8]), Lo: binary.BigEndian.Uint64(b[8:])}
+}
+
+// Bytes is an inverse operation of ID128FromBytes.
+func (id ID128) Bytes() (b [16]byte) {
+	binary.BigEndian.PutUint64(b[:8], id.Hi)
+	binary.BigEndian.PutUint64(b[8:], id.Lo)
+	return b
+}
+
+// IsZero reports if the id is the zero value.
+func (id ID128) IsZero() bool { return id == ID128{} }
+
+// String returns the obfuscated id in base64 string format, see
+// Obfuscator128.FormatID. It panics if the default 128 bits scheme could not
+// be created.
+func (id ID128) String() string { return mustDefault128().FormatID(id) }
+
+// ParseID128 is an inverse operation of ID128.String().
+func ParseID128(s string) (ID128, error) {
+	o, err := defaultObfuscator128()
+	if err != nil {
+		return ID128{}, err
+	}
+	return o.ParseID(s)
+}
+
+// MarshalJSON satisfies json.Marshaller and transparently obfuscates the value.
+func (id ID128) MarshalJSON() ([]byte, error) {
+	o, err := defaultObfuscator128()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(o.FormatID(id))
+}
+
+// UnmarshalJSON satisfies json.Unmarshaler and transparently deobfuscates
+// the value, the id is reset to zero if b can not be parsed.
+func (id *ID128) UnmarshalJSON(b []byte) (err error) {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*id, err = ParseID128(s)
+	return err
+}
+
+// xor returns id ^ x.
+func (id ID128) xor(x ID128) ID128 { return ID128{Hi: id.Hi ^ x.Hi, Lo: id.Lo ^ x.Lo} }
+
+// mul128 returns a * b modulo 1<<128.
+func mul128(a, b ID128) ID128 {
+	hi, lo := bits.Mul64(a.Lo, b.Lo)
+	hi += a.Hi*b.Lo + a.Lo*b.Hi
+	return ID128{Hi: hi, Lo: lo}
+}
+
+// id128ToBig returns id as a big.Int.
+func id128ToBig(id ID128) *big.Int {
+	b := id.Bytes()
+	return (&big.Int{}).SetBytes(b[:])
+}
+
+// id128FromBig returns the lower 128 bits of n.
+func id128FromBig(n *big.Int) ID128 {
+	var b [16]byte
+	n.FillBytes(b[:])
+	return ID128FromBytes(b)
+}
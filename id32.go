@@ -0,0 +1,123 @@
+package goobfuscated
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ID32 is the 32 bits counterpart of ID, for compact ids such as short links.
+// Its obfuscated value is 4 bytes, so String is 6 characters long instead of 11.
+type ID32 uint32
+
+var (
+	// default32 is the Obfuscator used by ID32, it is initialized on first use
+	// unless installed by SetDefault32. Like the default Obfuscator the
+	// initialized one is chosen at random and only lives as long as the
+	// process does.
+	default32     atomic.Pointer[Obfuscator]
+	default32Err  error
+	default32Once sync.Once
+)
+
+// defaultObfuscator32 returns the Obfuscator of ID32, creating it on first use.
+// It selects its prime from a table of 32 bits primes.
+func defaultObfuscator32() (*Obfuscator, error) {
+	if o := default32.Load(); o != nil {
+		return o, nil
+	}
+	default32Once.Do(func() {
+		o, err := NewObfuscator(WithBits(32), func(o *Obfuscator) error {
+			o.pool = primes32
+			return nil
+		})
+		if err != nil {
+			default32Err = err
+			return
+		}
+		default32.CompareAndSwap(nil, o)
+	})
+	if o := default32.Load(); o != nil {
+		return o, nil
+	}
+	return nil, default32Err
+}
+
+// SetDefault32 installs o as the Obfuscator of ID32, e.g. a seeded one so that
+// persisted short links keep resolving after a restart:
+//
+//	o, err := NewObfuscator(WithBits(32), WithSeed(seed))
+//	...
+//	err = SetDefault32(o)
+//
+// Only the scheme of o is used, the string form of ID32 stays the 4 bytes in
+// little-endian byte order encoded in base64. It returns an error if o is not
+// 32 bits wide, see WithBits, or is in the Sortable mode, whose values do not
+// fit in 32 bits. Like SetDefault, configure it once at startup.
+func SetDefault32(o *Obfuscator) error {
+	if o == nil {
+		return errors.New("obfuscator must not be nil")
+	}
+	if p := o.primary(); p.valueBits() != 32 || p.mode == Sortable {
+		return errors.New("obfuscator of ID32 must be 32 bits wide and not sortable")
+	}
+	default32.Store(o)
+	return nil
+}
+
+// mustDefault32 is like defaultObfuscator32 but panics on error.
+func mustDefault32() *Obfuscator {
+	o, err := defaultObfuscator32()
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+// Encode returns the obfuscated value of the id. It panics if the default
+// Obfuscator of ID32, see SetDefault32, could not be created.
+func (id ID32) Encode() uint32 { return uint32(mustDefault32().Obfuscate(uint64(id))) }
+
+// String returns the obfuscated id in base64 string format and with
+// little-endian byte order. It panics like Encode.
+func (id ID32) String() string {
+	buf := make([]byte, 4)
+	littleEndian.PutUint32(buf, id.Encode())
+	return urlEncoding.EncodeToString(buf)
+}
+
+// ParseID32 is an inverse operation of ID32.String().
+func ParseID32(s string) (ID32, error) {
+	o, err := defaultObfuscator32()
+	if err != nil {
+		return 0, err
+	}
+	switch buf, err := urlEncoding.DecodeString(s); {
+	case err != nil:
+		return 0, newError(ErrInvalidFormat, "fails to decode id: %w", err)
+	case len(buf) != 4: // ID32 expected to be exactly 4 bytes.
+		return 0, newError(ErrInvalidLength, "unexpected id format")
+	default:
+		return ID32(o.DeObfuscate(uint64(littleEndian.Uint32(buf)))), nil
+	}
+}
+
+// MarshalJSON satisfies json.Marshaller and transparently obfuscates the value.
+func (id ID32) MarshalJSON() ([]byte, error) {
+	if _, err := defaultObfuscator32(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(id.String())
+}
+
+// UnmarshalJSON satisfies json.Unmarshaler and transparently deobfuscates
+// the value, the id is reset to zero if b can not be parsed.
+func (id *ID32) UnmarshalJSON(b []byte) (err error) {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*id, err = ParseID32(s)
+	return err
+}
@@ -0,0 +1,239 @@
+package goobfuscated
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// FuzzParseID checks the invariants of the parsing of arbitrary strings, for
+// every built-in encoding: ParseID and ID.UnmarshalJSON never panic, and an
+// id returned by StrictParseID round-trips through FormatID and is the id
+// returned by ParseID.
+func FuzzParseID(f *testing.F) {
+	var obs []*Obfuscator
+	for name, enc := range encodings {
+		o, err := NewObfuscator(WithSeed(20200101), WithEncoding(enc))
+		if err != nil {
+			f.Fatalf("%s: %v", name, err)
+		}
+		obs = append(obs, o)
+		for _, id := range []ID{0, 1, 100, MaxInt} {
+			f.Add(o.FormatID(id))
+		}
+	}
+	for _, s := range []string{"", "-", "_", "====", "\x00\xff", `"`, "null", "1e3"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		var id ID
+		_ = id.UnmarshalJSON([]byte(s))
+		for _, o := range obs {
+			got, err := o.ParseID(s)
+			strict, serr := o.StrictParseID(s)
+			if serr != nil {
+				continue
+			}
+			if err != nil || got != strict {
+				t.Fatalf("ParseID(%q) = %d, %v, StrictParseID returns %d", s, got, err, strict)
+			}
+			if again, err := o.StrictParseID(o.FormatID(strict)); err != nil || again != strict {
+				t.Fatalf("id %d of %q does not round-trip: %d, %v", strict, s, again, err)
+			}
+		}
+	})
+}
+
+func TestObfuscateCheckedRange(t *testing.T) {
+	if _, err := ObfuscateChecked(MaxInt); err != nil {
+		t.Errorf("ObfuscateChecked(MaxInt) returns %v", err)
+	}
+	if _, err := ObfuscateChecked(MaxInt + 1); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("ObfuscateChecked(MaxInt+1) returns %v, want ErrOutOfRange", err)
+	}
+	id := ID(MaxInt + 1)
+	for name, marshal := range map[string]func() ([]byte, error){
+		"MarshalJSON":   id.MarshalJSON,
+		"MarshalText":   id.MarshalText,
+		"MarshalBinary": id.MarshalBinary,
+	} {
+		if b, err := marshal(); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("%s of MaxInt+1 returns %q, %v, want ErrOutOfRange", name, b, err)
+		}
+	}
+}
+
+func TestNewID(t *testing.T) {
+	setBenchDefault(t)
+	if id, err := NewID(MaxInt); err != nil || id != MaxInt {
+		t.Errorf("NewID(MaxInt) = %d, %v", id, err)
+	}
+	if id, err := NewID(MaxInt + 1); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("NewID(MaxInt+1) = %d, %v, want ErrOutOfRange", id, err)
+	}
+	if id := MustNewID(MaxInt); id != MaxInt {
+		t.Errorf("MustNewID(MaxInt) = %d", id)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustNewID(MaxInt+1) does not panic")
+		}
+	}()
+	MustNewID(MaxInt + 1)
+}
+
+// setBenchDefault installs the scheme of newBenchObfuscator as the default
+// for the duration of the test.
+func setBenchDefault(tb testing.TB) *Obfuscator {
+	tb.Helper()
+	resetDefault(tb)
+	o := newBenchObfuscator(tb)
+	SetDefault(o)
+	return o
+}
+
+func TestAppendJSON(t *testing.T) {
+	setBenchDefault(t)
+	for _, id := range []ID{0, 1, 100, MaxInt} {
+		want, err := id.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := id.AppendJSON([]byte("x")); err != nil || string(got) != "x"+string(want) {
+			t.Errorf("AppendJSON(%d) appends %s, %v, want %s", id, got[1:], err, want)
+		}
+	}
+	if got, err := ID(MaxInt + 1).AppendJSON([]byte("x")); !errors.Is(err, ErrOutOfRange) || string(got) != "x" {
+		t.Errorf("AppendJSON(%d) appends %q, %v, want ErrOutOfRange", uint64(MaxInt+1), got[1:], err)
+	}
+	buf := make([]byte, 0, 64)
+	if n := testing.AllocsPerRun(100, func() { buf, _ = ID(100).AppendJSON(buf[:0]) }); n != 0 && !raceEnabled {
+		t.Errorf("AppendJSON allocates %v times", n)
+	}
+}
+
+func BenchmarkAppendJSON(b *testing.B) {
+	setBenchDefault(b)
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	for i := range b.N {
+		var err error
+		if buf, err = ID(i).AppendJSON(buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMarshalString is the former MarshalJSON, which marshaled the
+// string of the id with encoding/json, for comparison with AppendJSON and
+// MarshalJSON.
+func BenchmarkMarshalString(b *testing.B) {
+	setBenchDefault(b)
+	b.ReportAllocs()
+	for i := range b.N {
+		if _, err := json.Marshal(ID(i).String()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	setBenchDefault(b)
+	b.ReportAllocs()
+	for i := range b.N {
+		if _, err := ID(i).MarshalJSON(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCustomWidthDefault(t *testing.T) {
+	for _, bits := range []int{32, 64} {
+		resetDefault(t)
+		o, err := NewObfuscator(WithSeed(20200101), WithBits(bits))
+		if err != nil {
+			t.Fatal(err)
+		}
+		SetDefault(o)
+		for _, id := range []uint64{1, 1<<32 - 1, MaxInt + 1} {
+			if id > o.limit() {
+				if _, err := ObfuscateChecked(id); !errors.Is(err, ErrOutOfRange) {
+					t.Errorf("bits %d: ObfuscateChecked(%d) returns %v, want ErrOutOfRange", bits, id, err)
+				}
+				if _, err := NewID(id); !errors.Is(err, ErrOutOfRange) {
+					t.Errorf("bits %d: NewID(%d) returns %v, want ErrOutOfRange", bits, id, err)
+				}
+				continue
+			}
+			n, err := ObfuscateChecked(id)
+			if err != nil || n != o.Obfuscate(id) || Obfuscate(id) != n {
+				t.Errorf("bits %d: ObfuscateChecked(%d) = %d, %v, want %d", bits, id, n, err, o.Obfuscate(id))
+			}
+			if got := DeObfuscate(n); got != id {
+				t.Errorf("bits %d: DeObfuscate(%d) = %d, want %d", bits, n, got, id)
+			}
+			if got, err := ParseID(ID(id).String()); err != nil || got != ID(id) {
+				t.Errorf("bits %d: %d parses to %d, %v", bits, id, got, err)
+			}
+		}
+	}
+}
+
+func TestEncodeAll(t *testing.T) {
+	setBenchDefault(t)
+	for _, id := range []ID{0, 1, 100, MaxInt} {
+		n, s := id.EncodeAll()
+		if n != id.Encode() || s != id.String() {
+			t.Errorf("EncodeAll(%d) = %d, %q, want %d, %q", id, n, s, id.Encode(), id.String())
+		}
+	}
+}
+
+func BenchmarkEncodeAll(b *testing.B) {
+	setBenchDefault(b)
+	b.ReportAllocs()
+	for i := range b.N {
+		_, _ = ID(i).EncodeAll()
+	}
+}
+
+func BenchmarkEncodeAndString(b *testing.B) {
+	setBenchDefault(b)
+	b.ReportAllocs()
+	for i := range b.N {
+		id := ID(i)
+		_, _ = id.Encode(), id.String()
+	}
+}
+
+// TestDefaultError checks that the error of the creation of the default
+// Obfuscator is returned by the functions which return an error, and that
+// the others panic with it.
+func TestDefaultError(t *testing.T) {
+	resetDefault(t)
+	want := errors.New("no entropy")
+	defaultOnce.Do(func() { defaultErr = want })
+	t.Cleanup(func() { defaultOnce, defaultErr = sync.Once{}, nil })
+
+	id := ID(100)
+	for name, f := range map[string]func() error{
+		"MarshalJSON": func() error { _, err := id.MarshalJSON(); return err },
+		"MarshalText": func() error { _, err := id.MarshalText(); return err },
+		"ParseID":     func() error { _, err := ParseID("AAAAAAAAAAA"); return err },
+		"NewID":       func() error { _, err := NewID(1); return err },
+		"WriteString": func() error { _, err := WriteString(io.Discard, id); return err },
+		"IDs":         func() error { _, err := IDs{id}.MarshalJSON(); return err },
+	} {
+		if err := f(); err != want {
+			t.Errorf("%s returns %v, want %v", name, err, want)
+		}
+	}
+	defer func() {
+		if r := recover(); r != want {
+			t.Errorf("String panics with %v, want %v", r, want)
+		}
+	}()
+	_ = id.String()
+}
@@ -0,0 +1,157 @@
+package goobfuscated
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// IDs is a list of ids which marshals to JSON as an array of obfuscated ids.
+// The array is encoded into a single buffer instead of marshaling each id on
+// its own.
+type IDs []ID
+
+// MarshalJSON satisfies json.Marshaller, it emits the ids like ID.MarshalJSON
+// does, as strings or as numbers if the default Obfuscator is configured
+// WithJSONNumber. A nil IDs is emitted as null.
+func (ids IDs) MarshalJSON() ([]byte, error) {
+	if ids == nil {
+		return []byte("null"), nil
+	}
+	o, err := defaultObfuscator()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 0, 2+len(ids)*16)
+	b = append(b, '[')
+	for i, id := range ids {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if b, err = o.appendJSON(b, id.Uint64()); err != nil {
+			return nil, err
+		}
+	}
+	return append(b, ']'), nil
+}
+
+// UnmarshalJSON satisfies json.Unmarshaler, each element is decoded like
+// ID.UnmarshalJSON does.
+func (ids *IDs) UnmarshalJSON(b []byte) error { return json.Unmarshal(b, (*[]ID)(ids)) }
+
+// Strings returns the obfuscated strings of the ids, see ID.String. Like the
+// latter, it panics if the default Obfuscator could not be created.
+func (ids IDs) Strings() []string {
+	o := mustDefault()
+	ss := make([]string, len(ids))
+	for i, id := range ids {
+		ss[i] = o.FormatID(id)
+	}
+	return ss
+}
+
+// Values returns the raw integer values of the ids.
+func (ids IDs) Values() []uint64 {
+	ns := make([]uint64, len(ids))
+	for i, id := range ids {
+		ns[i] = uint64(id)
+	}
+	return ns
+}
+
+// ParseErrors is the error of ParseIDs and ReencodeSlice, it maps the index
+// of each string which failed to parse to its error.
+type ParseErrors map[int]error
+
+func (e ParseErrors) Error() string {
+	indexes := make([]int, 0, len(e))
+	for i := range e {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	if len(indexes) == 1 {
+		return fmt.Sprintf("id at index %d: %v", indexes[0], e[indexes[0]])
+	}
+	return fmt.Sprintf("%d ids fail to parse, first at index %d: %v", len(indexes), indexes[0], e[indexes[0]])
+}
+
+// ParseIDs parses each string of ss like ParseID does, the strings are decoded
+// with a single reused buffer. Unlike looping over ParseID, all the strings
+// are parsed: the ids of the strings which fail to parse are zero and the
+// returned error is a ParseErrors holding the error of each of them.
+func (o *Obfuscator) ParseIDs(ss []string) ([]ID, error) {
+	ids := make([]ID, len(ss))
+	var errs ParseErrors
+	bp := bufPool.Get().(*[]byte)
+	defer bufPool.Put(bp)
+	for i, s := range ss {
+		if o.zeroSet && s == o.zeroString {
+			continue
+		}
+		buf, err := o.appendDecode((*bp)[:0], s)
+		*bp = buf[:0]
+		if err == nil {
+			var n uint64
+			if n, err = o.checkValue(buf); err == nil {
+				ids[i] = ID(o.DeObfuscate(n))
+				continue
+			}
+		}
+		if errs == nil {
+			errs = ParseErrors{}
+		}
+		errs[i] = err
+	}
+	if errs != nil {
+		return ids, errs
+	}
+	return ids, nil
+}
+
+// ParseIDs parses each string of ss with the default Obfuscator, see
+// Obfuscator.ParseIDs.
+func ParseIDs(ss []string) ([]ID, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return nil, err
+	}
+	return o.ParseIDs(ss)
+}
+
+// Len, Less and Swap satisfy sort.Interface, ids sort by their raw value, see
+// ID.Compare.
+func (ids IDs) Len() int           { return len(ids) }
+func (ids IDs) Less(i, j int) bool { return ids[i] < ids[j] }
+func (ids IDs) Swap(i, j int)      { ids[i], ids[j] = ids[j], ids[i] }
+
+// appendJSON appends the JSON form of id to b, the obfuscated string or
+// number, see WithJSONNumber.
+func (o *Obfuscator) appendJSON(b []byte, id uint64) ([]byte, error) {
+	if o.jsonNumber {
+		n, err := o.ObfuscateChecked(id)
+		if err != nil {
+			return b, err
+		}
+		return strconv.AppendUint(b, n, 10), nil
+	}
+	return o.appendJSONString(b, id)
+}
+
+// appendJSONString appends the string form of id to b as a JSON string. The
+// built-in encodings never need escaping, so the string is quoted in place,
+// otherwise quoting is left to encoding/json.
+func (o *Obfuscator) appendJSONString(b []byte, id uint64) ([]byte, error) {
+	start := len(b)
+	b, err := o.appendID(append(b, '"'), id)
+	if err != nil {
+		return b[:start], err
+	}
+	for _, c := range b[start+1:] {
+		if c < 0x20 || c >= 0x7f || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			q, err := json.Marshal(string(b[start+1:]))
+			return append(b[:start], q...), err
+		}
+	}
+	return append(b, '"'), nil
+}
@@ -0,0 +1,63 @@
+package goobfuscated
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseIDs(t *testing.T) {
+	o := newBenchObfuscator(t)
+	ss := []string{o.FormatID(1), "bad", o.FormatID(100), "", o.FormatID(MaxInt)}
+	ids, err := o.ParseIDs(ss)
+	if want := []ID{1, 0, 100, 0, MaxInt}; len(ids) != len(want) {
+		t.Fatalf("ParseIDs returns %d ids, want %d", len(ids), len(want))
+	} else {
+		for i := range want {
+			if ids[i] != want[i] {
+				t.Errorf("ParseIDs()[%d] = %d, want %d", i, ids[i], want[i])
+			}
+		}
+	}
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 2 || errs[1] == nil || errs[3] == nil {
+		t.Errorf("ParseIDs returns %v, want the errors of the indexes 1 and 3", err)
+	}
+}
+
+// benchStrings returns the string forms of benchIDs by o.
+func benchStrings(o *Obfuscator) []string {
+	ss := make([]string, len(benchIDs))
+	for i, id := range benchIDs {
+		ss[i] = o.FormatID(ID(id))
+	}
+	return ss
+}
+
+func BenchmarkParseIDs(b *testing.B) {
+	o := newBenchObfuscator(b)
+	ss := benchStrings(o)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := o.ParseIDs(ss); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseIDLoop(b *testing.B) {
+	o := newBenchObfuscator(b)
+	ss := benchStrings(o)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		ids := make([]ID, len(ss))
+		for i, s := range ss {
+			id, err := o.ParseID(s)
+			if err != nil {
+				b.Fatal(err)
+			}
+			ids[i] = id
+		}
+	}
+}
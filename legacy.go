@@ -0,0 +1,79 @@
+package goobfuscated
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// legacyEncodings are the base64 variants tried by ParseIDAny, in order.
+var legacyEncodings = []*base64.Encoding{
+	base64.RawURLEncoding,
+	base64.URLEncoding,
+	base64.RawStdEncoding,
+	base64.StdEncoding,
+}
+
+// ParseIDAny is like ParseID, but it also accepts ids encoded with the other
+// base64 variants: it tries the raw and the padded URL encoding, then the raw
+// and the padded standard encoding ("+", "/" and "=") and returns the id of
+// the first one which decodes s into 8 bytes. It is meant to read ids minted
+// before a switch from base64.StdEncoding, the prefix is optional.
+//
+// The variants only differ in the characters of the values 62 and 63 and in
+// the padding, so a string decodes to the same id with each variant accepting
+// it. The risk is the other way round: several strings parse to the same id,
+// so the strings accepted by ParseIDAny must not be compared or used as keys,
+// compare the ids or the strings returned by FormatID instead.
+func (o *Obfuscator) ParseIDAny(s string) (ID, error) {
+	if len(s) > o.maxLen {
+		return 0, newError(ErrInvalidLength, "fails to decode id: too long")
+	}
+	s = strings.TrimPrefix(s, o.prefix)
+	for _, enc := range legacyEncodings {
+		if buf, err := enc.DecodeString(s); err == nil && len(buf) == 8 {
+			return o.parseBinary(buf)
+		}
+	}
+	return 0, newError(ErrInvalidFormat, "fails to decode id: not a base64 id")
+}
+
+// ParseIDAny is like ParseID, but it accepts each base64 variant, see
+// Obfuscator.ParseIDAny.
+func ParseIDAny(s string) (ID, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	return o.ParseIDAny(s)
+}
+
+// ParseIDCompat is like ParseID, but it also accepts the strings of 4 bytes
+// minted by legacy, a 32 bits scheme such as the one of ID32, e.g. during a
+// migration from 32 bits ids. The width is told apart by the length of the
+// decoded bytes: 4 bytes, 6 characters in base64, are deobfuscated by legacy
+// into the lower 32 bits of the id, any other length is parsed by o. The id
+// spaces overlap, the legacy id 7 and the id 7 are the same ID, so both
+// schemes must number the same rows. The prefix of o is optional for the
+// legacy strings. It returns an error if legacy is wider than 32 bits, or if
+// o is WithCompact, whose strings of 4 bytes are ids of o.
+func (o *Obfuscator) ParseIDCompat(s string, legacy *Obfuscator) (ID, error) {
+	if legacy.bits > 32 {
+		return 0, fmt.Errorf("legacy scheme of %d bits is wider than 32 bits", legacy.bits)
+	}
+	if o.compact {
+		return 0, errors.New("compact ids can not be told apart from legacy ids")
+	}
+	if len(s) > o.maxLen {
+		return 0, newError(ErrInvalidLength, "fails to decode id: too long")
+	}
+	if buf, err := o.enc.DecodeString(strings.TrimPrefix(s, o.prefix)); err == nil && len(buf) == 4 {
+		id, ok := legacy.deObfuscate(uint64(legacy.order.Uint32(buf)))
+		if !ok {
+			return 0, ErrOutOfRange
+		}
+		return ID(id), nil
+	}
+	return o.ParseID(s)
+}
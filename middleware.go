@@ -0,0 +1,166 @@
+package goobfuscated
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParamExtractor returns the value of the route parameter name of r, or an
+// empty string if r has no such parameter. chi users pass chi.URLParam,
+// gorilla users a function reading mux.Vars(r)[name].
+type ParamExtractor func(r *http.Request, name string) string
+
+// Middleware returns a middleware which deobfuscates the route parameters
+// named paramNames with o, so handlers see raw ids, and which obfuscates the
+// ids of the Location header of the response. The parameters are read from
+// the patterns of http.ServeMux, see MiddlewareWith for other routers. The
+// middleware must wrap the handler registered on the mux, the parameters are
+// only known once the mux matched the request.
+func Middleware(o *Obfuscator, paramNames ...string) func(http.Handler) http.Handler {
+	return MiddlewareWith(o, (*http.Request).PathValue, paramNames...)
+}
+
+// MiddlewareWith is like Middleware, but it reads the parameters with extract.
+//
+// A request with a parameter which is not a valid obfuscated id, see
+// StrictParseID, is answered with 400 Bad Request before reaching the handler.
+// The raw ids are stored in the request context, see IDFromContext, and the
+// path values of the request are replaced by the decimal ids, so handlers of
+// http.ServeMux keep calling PathValue.
+//
+// The Location header of the response is rewritten if its path matches the
+// pattern of the route, see http.Request.Pattern: only the segments of the
+// wildcards named in paramNames are obfuscated, e.g. with the pattern
+// "/users/{id}/page/{page}" and the parameter "id", the Location
+// "/users/42/page/2" has 42 obfuscated and the page left as it is. The
+// Location of another route, e.g. the one of a created resource, is left as it
+// is, as are the Locations under the routers which do not set the pattern:
+// format its ids with FormatID.
+func MiddlewareWith(o *Obfuscator, extract ParamExtractor, paramNames ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ids := make(map[string]ID, len(paramNames))
+			for _, name := range paramNames {
+				s := extract(r, name)
+				if s == "" {
+					continue
+				}
+				id, err := o.StrictParseID(s)
+				if err != nil {
+					http.Error(w, "invalid id "+strconv.Quote(name), http.StatusBadRequest)
+					return
+				}
+				ids[name] = id
+			}
+			if len(ids) > 0 {
+				r = r.WithContext(context.WithValue(r.Context(), idsKey{}, ids))
+				for name, id := range ids {
+					r.SetPathValue(name, strconv.FormatUint(id.Uint64(), 10))
+				}
+			}
+			next.ServeHTTP(&locationWriter{ResponseWriter: w, o: o, pattern: r.Pattern, names: paramNames}, r)
+		})
+	}
+}
+
+// IDFromContext returns the raw id of the route parameter name deobfuscated by
+// Middleware or MiddlewareWith.
+func IDFromContext(ctx context.Context, name string) (ID, bool) {
+	id, ok := ctx.Value(idsKey{}).(map[string]ID)[name]
+	return id, ok
+}
+
+// idsKey is the context key of the ids of the route parameters.
+type idsKey struct{}
+
+// locationWriter obfuscates the ids of the Location header before the header
+// is written.
+type locationWriter struct {
+	http.ResponseWriter
+	o           *Obfuscator
+	pattern     string   // pattern of the route, see http.Request.Pattern.
+	names       []string // wildcards of pattern holding ids.
+	wroteHeader bool
+}
+
+func (w *locationWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if loc := w.Header().Get("Location"); loc != "" {
+			w.Header().Set("Location", w.o.obfuscateLocation(loc, w.pattern, w.names))
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *locationWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *locationWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// obfuscateLocation obfuscates the decimal ids of the path segments of loc at
+// the wildcards of pattern named in names. loc is returned as it is unless its
+// path matches pattern.
+func (o *Obfuscator) obfuscateLocation(loc, pattern string, names []string) string {
+	// The method and the host of the pattern, e.g. "GET example.com/", are
+	// not part of the path.
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		pattern = pattern[i+1:]
+	}
+	if i := strings.IndexByte(pattern, '/'); i >= 0 {
+		pattern = pattern[i:]
+	} else {
+		return loc
+	}
+	u, err := url.Parse(loc)
+	if err != nil {
+		return loc
+	}
+	segs, wilds := strings.Split(u.Path, "/"), strings.Split(pattern, "/")
+	rest := false // the last wildcard matches the remainder of the path.
+	if last := wilds[len(wilds)-1]; strings.HasPrefix(last, "{") && strings.HasSuffix(last, "...}") {
+		wilds, rest = wilds[:len(wilds)-1], true
+	}
+	if len(segs) < len(wilds) || len(segs) > len(wilds) && !rest {
+		return loc
+	}
+	rewrote := false
+	for i, w := range wilds {
+		name, ok := strings.CutPrefix(w, "{")
+		if ok {
+			name, ok = strings.CutSuffix(name, "}")
+		}
+		if !ok {
+			if w != segs[i] {
+				return loc
+			}
+			continue
+		}
+		switch {
+		case name == "$":
+			if segs[i] != "" {
+				return loc
+			}
+		case slices.Contains(names, name):
+			n, err := strconv.ParseUint(segs[i], 10, 64)
+			if err != nil || n > o.limit() {
+				return loc
+			}
+			segs[i], rewrote = o.FormatID(ID(n)), true
+		}
+	}
+	if !rewrote {
+		return loc
+	}
+	u.Path, u.RawPath = strings.Join(segs, "/"), ""
+	return u.String()
+}
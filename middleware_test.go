@@ -0,0 +1,81 @@
+package goobfuscated
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestMux routes the pattern to handler wrapped by the Middleware of o
+// translating the ids of names.
+func newTestMux(o *Obfuscator, pattern string, handler http.HandlerFunc, names ...string) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle(pattern, Middleware(o, names...)(handler))
+	return mux
+}
+
+func TestMiddleware(t *testing.T) {
+	o := newBenchObfuscator(t)
+	called := false
+	mux := newTestMux(o, "GET /users/{id}/page/{page}", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if id, ok := IDFromContext(r.Context(), "id"); !ok || id != 42 {
+			t.Errorf("IDFromContext returns %d, %v, want 42", id, ok)
+		}
+		if _, ok := IDFromContext(r.Context(), "page"); ok {
+			t.Error("IDFromContext returns the page")
+		}
+		if got := r.PathValue("id"); got != "42" {
+			t.Errorf("PathValue(id) = %q, want 42", got)
+		}
+		if got := r.PathValue("page"); got != "2" {
+			t.Errorf("PathValue(page) = %q, want 2", got)
+		}
+		w.Header().Set("Location", "/users/42/page/3?sort=2")
+		w.WriteHeader(http.StatusFound)
+	}, "id")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/users/"+o.FormatID(42)+"/page/2", nil))
+	if !called {
+		t.Fatalf("the handler is not called, status %d", rec.Code)
+	}
+	if got, want := rec.Header().Get("Location"), "/users/"+o.FormatID(42)+"/page/3?sort=2"; got != want {
+		t.Errorf("Location = %q, want %q", got, want)
+	}
+}
+
+func TestMiddlewareBadRequest(t *testing.T) {
+	o := newBenchObfuscator(t)
+	mux := newTestMux(o, "GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the handler is called")
+	}, "id")
+	for _, path := range []string{"/users/42", "/users/not-an-id"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", path, rec.Code)
+		}
+	}
+}
+
+func TestObfuscateLocation(t *testing.T) {
+	o := newBenchObfuscator(t)
+	obf := o.FormatID(42)
+	for _, tt := range []struct {
+		pattern, loc, want string
+	}{
+		{"/users/{id}/page/{page}", "/users/42/page/2", "/users/" + obf + "/page/2"},
+		{"GET example.com/users/{id}", "https://example.com/users/42", "https://example.com/users/" + obf},
+		{"/users/{id}/{rest...}", "/users/42/page/2", "/users/" + obf + "/page/2"},
+		{"/users/{id}/{$}", "/users/42/", "/users/" + obf + "/"},
+		{"/users/{id}", "/orders/42", "/orders/42"},
+		{"/users/{id}", "/users/42/page/2", "/users/42/page/2"},
+		{"/users/{id}", "/users/" + obf, "/users/" + obf},
+		{"/users", "/users/42", "/users/42"},
+		{"", "/users/42", "/users/42"},
+	} {
+		if got := o.obfuscateLocation(tt.loc, tt.pattern, []string{"id"}); got != tt.want {
+			t.Errorf("pattern %q: %q is rewritten to %q, want %q", tt.pattern, tt.loc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Package msgpackid encodes the obfuscated ids in the MessagePack documents of
+// github.com/vmihailenco/msgpack/v5 as 8 bytes bins, it is kept apart from
+// goobfuscated so that only its users depend on msgpack.
+package msgpackid
+
+import (
+	"github.com/19byte/goobfuscated"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ID is a goobfuscated.ID whose MessagePack form is a bin holding the 8 bytes
+// of MarshalBinary, 10 bytes on the wire, instead of the obfuscated string.
+// The other methods of the embedded ID, JSON, SQL and text, are promoted.
+type ID struct{ goobfuscated.ID }
+
+// EncodeMsgpack satisfies msgpack.CustomEncoder, the id is encoded as a bin
+// holding the 8 bytes of MarshalBinary.
+func (id ID) EncodeMsgpack(enc *msgpack.Encoder) error {
+	b, err := id.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return enc.EncodeBytes(b)
+}
+
+// DecodeMsgpack satisfies msgpack.CustomDecoder and is the inverse of
+// EncodeMsgpack, it returns an error unless the bin is exactly 8 bytes.
+func (id *ID) DecodeMsgpack(dec *msgpack.Decoder) error {
+	b, err := dec.DecodeBytes()
+	if err != nil {
+		return err
+	}
+	return id.UnmarshalBinary(b)
+}
@@ -0,0 +1,60 @@
+package msgpackid
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/19byte/goobfuscated"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgpack(t *testing.T) {
+	o, err := goobfuscated.NewObfuscator(goobfuscated.WithSeed(20200101))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goobfuscated.SetDefault(o)
+	for _, id := range []goobfuscated.ID{0, 1, 100, goobfuscated.MaxInt} {
+		b, err := msgpack.Marshal(ID{id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := id.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// bin 8 header, length, then the 8 bytes.
+		if want := append([]byte{0xc4, 8}, raw...); !bytes.Equal(b, want) {
+			t.Errorf("%d encodes to %x, want %x", id, b, want)
+		}
+		var got ID
+		if err := msgpack.Unmarshal(b, &got); err != nil || got.ID != id {
+			t.Errorf("%x decodes to %d, %v, want %d", b, got.ID, err, id)
+		}
+	}
+	type doc struct {
+		ID   ID     `msgpack:"id"`
+		Name string `msgpack:"name"`
+	}
+	b, err := msgpack.Marshal(doc{ID{100}, "golang"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got doc
+	if err := msgpack.Unmarshal(b, &got); err != nil || got.ID.ID != 100 || got.Name != "golang" {
+		t.Errorf("%x decodes to %+v, %v", b, got, err)
+	}
+}
+
+func TestMsgpackInvalid(t *testing.T) {
+	for _, v := range []any{[]byte{1, 2, 3}, "Q7HnWooQAgA"} {
+		b, err := msgpack.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got ID
+		if err := msgpack.Unmarshal(b, &got); err == nil {
+			t.Errorf("%x decodes to %d", b, got.ID)
+		}
+	}
+}
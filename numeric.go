@@ -0,0 +1,29 @@
+package goobfuscated
+
+import "strconv"
+
+// NumericID is an ID which always marshals to JSON as a number, the
+// obfuscated value returned by ID.Encode. JavaScript can hold it safely as
+// long as the width of the scheme does not exceed 53 bits (MaxInt).
+type NumericID ID
+
+// MarshalJSON satisfies json.Marshaller, it emits the obfuscated value as a
+// JSON number.
+func (id NumericID) MarshalJSON() ([]byte, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return nil, err
+	}
+	n, err := o.ObfuscateChecked(uint64(id))
+	if err != nil {
+		return nil, err
+	}
+	return strconv.AppendUint(nil, n, 10), nil
+}
+
+// UnmarshalJSON satisfies json.Unmarshaler, it accepts both the JSON number
+// and the obfuscated string, see ID.UnmarshalJSON.
+func (id *NumericID) UnmarshalJSON(b []byte) error { return (*ID)(id).UnmarshalJSON(b) }
+
+// ID returns id as an ID.
+func (id NumericID) ID() ID { return ID(id) }
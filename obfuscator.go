@@ -0,0 +1,1010 @@
+package goobfuscated
+
+import (
+	"bytes"
+	"crypto/hkdf"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"math/big"
+	"math/bits"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Obfuscator holds a single obfuscation scheme: the prime, its mod inverse
+// and the random xor mask. Independent obfuscators can be used side by side
+// in the same binary.
+type Obfuscator struct {
+	prime      uint64 // prime used to obfuscate the id.
+	modInverse uint64 // mod inverse of prime, used to deobfuscate.
+	random     uint64 // random xor mask.
+	max        uint64 // upper bound of the id space, it is also the bit mask.
+	bits       int    // width of the id space, max is 1<<bits - 1.
+
+	order      binary.ByteOrder // byte order of the encoded id.
+	enc        Encoding         // encoding of the string form.
+	prefix     string           // prefix of the string form, see WithPrefix.
+	padded     bool             // emit padded base64, see WithPadding.
+	zeroString string           // string form of the zero id, see WithZeroString.
+	zeroSet    bool             // zeroString is configured.
+	domain     string           // name of the domain, see WithDomainSalt.
+	jsonNumber bool             // marshal ID to JSON as a number.
+	minLen     int              // minimal length of the encoded id.
+	padLen     int              // number of padding bytes, see WithMinLength.
+	maxLen     int              // longest string accepted by ParseID.
+	width      int              // fixed width of the encoded id, see WithFixedWidth.
+	zeroSym    byte             // symbol of the zero byte, pads to width.
+	nonceBits  int              // upper bits holding a nonce, see WithNonce.
+	compact    bool             // strip the leading zero bytes, see WithCompact.
+	tag        uint64           // bit marking the obfuscated values, see WithTagBit.
+	version    uint64           // version of the scheme in its bits, see WithVersion.
+	versionBit int              // number of bits of the version.
+
+	seed     [32]byte // key of the generator the scheme is drawn from.
+	seeded   bool     // seed is configured, see WithSeed.
+	seedBits int      // bits of the configured seed, see SecurityReport.
+	pool     []uint64 // primes to select the prime from.
+
+	primeRand io.Reader // source of a generated prime, see WithRandomPrime.
+
+	mode   Mode
+	perm   permutation // replaces Knuth's hashing in the alternative modes.
+	rounds int         // rounds of the Feistel network, see WithFeistel.
+	key    []byte      // key of the Feistel network and the keyed mode.
+
+	mu   sync.Mutex              // serializes RotateTo.
+	keys atomic.Pointer[keyring] // schemes after rotation, nil before.
+
+	cache *parseCache // ids returned by ParseID, see WithParseCache.
+}
+
+// Option configures an Obfuscator created by NewObfuscator.
+type Option func(*Obfuscator) error
+
+// Mode is the algorithm used by an Obfuscator, every mode is a bijection over
+// the id space so DeObfuscate is always exact.
+type Mode int
+
+const (
+	// Multiplicative is Knuth's multiplicative hashing, the default mode.
+	// It hides the sequence of ids, but it is linear: a few pairs of ids and
+	// obfuscated values are enough to recover the prime and the mask.
+	Multiplicative Mode = iota
+
+	// Feistel runs ids through a balanced Feistel network, see WithFeistel.
+	Feistel
+
+	// Keyed runs ids through a Feistel network with HMAC-SHA256 as round
+	// function, in the fashion of the FF1 format preserving encryption.
+	// It targets secrecy: ids can not be obfuscated nor recovered without the
+	// key, which must be configured WithKey.
+	Keyed
+
+	// Sortable preserves the order of ids: if a < b then the obfuscated value
+	// and the string form of a sort before those of b. It leaks the order of
+	// ids by design, and it is NOT encryption: the value is proportional to
+	// the id, so the ratio of two values is about the ratio of their ids, and
+	// anyone holding a single pair of an id and its value can read the id of
+	// any value.
+	//
+	// The obfuscated value is the id multiplied by a secret stride plus keyed
+	// noise below the stride, at least 8 bits of it, so the width is limited
+	// to 56 bits. The bytes are laid out in big-endian byte order and encoded
+	// with SortableBase64 unless another encoding is configured, which must
+	// then be fixed length and preserve the order too, e.g. Hex or Crockford.
+	Sortable
+)
+
+// defaultRounds is the number of rounds of WithMode(Feistel).
+const defaultRounds = 8
+
+var (
+	// defaultObf is the Obfuscator used by the package level functions,
+	// it is initialized on first use unless installed by SetDefault. The
+	// initialized one is not seeded, so the ids it produces are only valid for
+	// the lifetime of the process.
+	defaultObf  atomic.Pointer[Obfuscator]
+	defaultErr  error
+	defaultOnce sync.Once
+)
+
+// NewObfuscator returns a new Obfuscator configured by opts.
+//
+// Without WithSeed the scheme is chosen at random, so it only lives as long
+// as the process does: values obfuscated by it can not be decoded after a
+// restart. Use WithSeed for any id that is persisted or handed out to clients.
+func NewObfuscator(opts ...Option) (*Obfuscator, error) {
+	o := &Obfuscator{max: MaxInt, bits: 53, order: littleEndian, enc: urlEncoding, pool: primes}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	// The upper bit of a tagged scheme marks the obfuscated values, the ids
+	// are permuted over the bits below it.
+	if o.tag != 0 {
+		if o.bits <= MinBits {
+			return nil, fmt.Errorf("tag bit leaves less than %d bits of the %d bits id space", MinBits, o.bits)
+		}
+		o.bits--
+		o.max >>= 1
+		o.tag = o.max + 1
+	}
+
+	// The version of the scheme is held by the bits below the tag, the ids
+	// are permuted over the bits below it.
+	if o.versionBit != 0 {
+		if o.bits-o.versionBit < MinBits {
+			return nil, fmt.Errorf("version of %d bits leaves less than %d bits of the %d bits id space", o.versionBit, MinBits, o.bits)
+		}
+		if o.version >= 1<<o.versionBit {
+			return nil, fmt.Errorf("version %d does not fit in %d bits", o.version, o.versionBit)
+		}
+		if o.mode == Sortable {
+			return nil, errors.New("sortable mode does not support a version")
+		}
+		o.bits -= o.versionBit
+		o.max >>= o.versionBit
+		o.version <<= o.bits
+	}
+
+	// Create a ChaCha8 generator keyed by the configured seed, or by a random
+	// one drawn from crypto/rand: the selection of the prime must go through
+	// rng, not a global source, for WithSeed to be deterministic. The whole
+	// 256 bits of the seed key the generator, where rand.NewSource of
+	// math/rand reduces its seed modulo 2^31-1.
+	if !o.seeded {
+		if _, err := io.ReadFull(crand.Reader, o.seed[:]); err != nil {
+			return nil, fmt.Errorf("fails to read seed: %w", err)
+		}
+	}
+	rng := rand.New(rand.NewChaCha8(o.seed))
+
+	// Random a PRIME number from local primes. It must be smaller
+	// than the upper bound (MAX ID). The index is drawn even if the prime is
+	// configured, so that a seeded mask does not depend on WithPrime
+	// or WithRandomPrime.
+	prime := o.pool[rng.IntN(len(o.pool))]
+	if o.prime == 0 && o.primeRand != nil {
+		p, err := randPrime(o.primeRand, o.bits)
+		if err != nil {
+			return nil, err
+		}
+		prime = p
+	}
+	if o.prime == 0 {
+		o.prime = prime
+	}
+
+	// prime must be a valid prime.
+	if !isPrime(o.prime) {
+		return nil, fmt.Errorf("prime is not a valid prime. [Accuracy: %f]", accuracy())
+	}
+
+	// Calculate the Mod Inverse of the Prime number such that
+	// (PRIME * INVERSE) & MAX ID == 1.
+	inverse, err := ModInverse(o.prime, o.max+1)
+	if err != nil {
+		return nil, err
+	}
+	o.modInverse = inverse
+
+	if o.nonceBits >= o.bits {
+		return nil, fmt.Errorf("nonce of %d bits leaves no bits of the %d bits id space", o.nonceBits, o.bits)
+	}
+
+	switch o.mode {
+	case Feistel:
+		if len(o.key) == 0 {
+			return nil, errors.New("feistel mode requires a key")
+		}
+		if o.rounds == 0 {
+			o.rounds = defaultRounds
+		}
+		o.perm = newFeistel(o.bits, o.rounds, o.key)
+	case Keyed:
+		if len(o.key) == 0 {
+			return nil, errors.New("keyed mode requires a key")
+		}
+		o.perm = newKeyed(o.bits, o.key)
+	case Sortable:
+		if o.nonceBits != 0 {
+			return nil, errors.New("sortable mode does not support a nonce")
+		}
+		if o.tag != 0 {
+			return nil, errors.New("sortable mode does not support the tag bit")
+		}
+		if o.bits > sortableMaxBits {
+			return nil, fmt.Errorf("sortable mode supports at most %d bits", sortableMaxBits)
+		}
+		o.order = binary.BigEndian
+		if o.enc == urlEncoding {
+			o.enc = SortableBase64
+		}
+	}
+
+	if o.padded {
+		if o.enc != urlEncoding && o.enc != Base64Padded {
+			return nil, errors.New("padding requires the Base64 encoding")
+		}
+		o.enc = Base64Padded
+	}
+
+	if err := verifyEncoding(o.enc); err != nil {
+		return nil, err
+	}
+
+	// Find the number of padding bytes which makes the encoded id at least
+	// minLen characters long.
+	for o.minLen > 0 && len(o.enc.EncodeToString(make([]byte, 8+o.padLen))) < o.minLen {
+		o.padLen++
+	}
+
+	// Compact ids strip the leading zero bytes of the big-endian value.
+	if o.compact {
+		if o.mode == Sortable || o.minLen > 0 || o.width != 0 {
+			return nil, errors.New("compact ids are of variable length, they can not be sortable, of a min length or of a fixed width")
+		}
+		o.order = binary.BigEndian
+	}
+
+	// Variable length encodings, e.g. Base58, are left-padded with the symbol
+	// of the zero byte to the longest encoded id.
+	if o.width != 0 {
+		o.width = len(o.enc.EncodeToString(bytes.Repeat([]byte{0xff}, 8+o.padLen)))
+		if zero := o.enc.EncodeToString([]byte{0}); len(zero) == 1 {
+			o.zeroSym = zero[0]
+		} else if len(o.enc.EncodeToString(make([]byte, 8+o.padLen))) == o.width {
+			o.width = 0 // fixed length already.
+		} else {
+			return nil, errors.New("fixed width requires an encoding with a zero symbol")
+		}
+	}
+
+	// Bound the length of the strings to decode, with room for the checksum
+	// of ParseIDWithCheck and for separators, e.g. those of Crockford. The
+	// decoding of variable length encodings, e.g. Base58, is quadratic.
+	o.maxLen = len(o.prefix) + 2*encodedLen(o.enc, 9+o.padLen)
+
+	if _, err := o.decodeValue(o.zeroString); o.zeroSet && err == nil {
+		return nil, fmt.Errorf("zero string %q is the string of an id", o.zeroString)
+	}
+
+	// Generate a Pure Random Integer less than the upper bound (MAX ID). A seeded
+	// scheme draws from rng instead so that it is reproducible.
+	switch {
+	case o.random != 0:
+		if o.random > o.max {
+			return nil, fmt.Errorf("mask %d is out of range [1,%d]", o.random, o.max)
+		}
+	case o.seeded:
+		o.random = rng.Uint64N(o.max-1) + 1
+	default:
+		o.random = randN(o.max - 1)
+	}
+	if o.mode == Sortable {
+		o.perm = newSortable(o.bits, o.random)
+	}
+	// The scheme of a domain is derived as a whole, its prime and its key as
+	// well as its mask: a domain which only changed the mask would leave the
+	// xor of the values of two domains the same for every id.
+	if o.domain != "" {
+		o.derive("domain\x00" + o.domain)
+	}
+	return o, nil
+}
+
+// WithNewMask returns a new Obfuscator sharing the prime and its inverse with
+// o, but with mask as the random xor mask, reduced to the width of the id
+// space. The derived Obfuscator round-trips like o, while the mapping is a
+// different one: values minted by o do not decode with it, and the other way
+// round, unless it is made to accept them, see RotateTo. The keyed modes do
+// not use the mask, their mapping is unchanged. A rotated o derives from its
+// primary scheme, the retired schemes are not carried over.
+func (o *Obfuscator) WithNewMask(mask uint64) *Obfuscator {
+	d := o.clone()
+	d.random = mask & d.max
+	if d.mode == Sortable {
+		d.perm = newSortable(d.bits, d.random)
+	}
+	return d
+}
+
+// Derive returns a child Obfuscator of the namespace, e.g. the name of a
+// tenant. The prime, the mask and the key of the keyed modes of the child are
+// derived with HKDF-SHA256 from the scheme of o, its seed, prime, mask and
+// key, and from namespace: the same scheme and namespace always derive the
+// same child, so per-tenant schemes need no key table, while the children of
+// distinct namespaces obfuscate differently. The child keeps the mode, the
+// width and the string form of o.
+func (o *Obfuscator) Derive(namespace string) *Obfuscator {
+	d := o.clone()
+	d.derive(namespace)
+	return d
+}
+
+// derive replaces the scheme of o, which must not be rotated, by the one
+// derived from it and from info, see Derive. A scheme of WithRandomPrime
+// derives a random prime, the others a prime of their pool.
+func (o *Obfuscator) derive(info string) {
+	out, err := hkdf.Key(sha256.New, o.secret(), nil, info, 56)
+	if err != nil {
+		panic(err) // 56 bytes are far below the limit of HKDF-SHA256.
+	}
+	o.prime = o.pool[littleEndian.Uint64(out)%uint64(len(o.pool))]
+	if o.primeRand != nil {
+		o.prime, _ = randPrime(bytes.NewReader(out[48:]), o.bits) // reads 8 bytes.
+	}
+	o.modInverse, _ = ModInverse(o.prime, o.max+1) // the primes are odd.
+	o.random = littleEndian.Uint64(out[8:])%o.max + 1
+	switch o.mode {
+	case Feistel:
+		o.key = out[16:48]
+		o.perm = newFeistel(o.bits, o.rounds, o.key)
+	case Keyed:
+		o.key = out[16:48]
+		o.perm = newKeyed(o.bits, o.key)
+	case Sortable:
+		o.perm = newSortable(o.bits, o.random)
+	}
+}
+
+// secret returns the secret material of the scheme of o, its seed, prime, mask
+// and key.
+func (o *Obfuscator) secret() []byte {
+	secret := make([]byte, 48, 48+len(o.key))
+	copy(secret, o.seed[:])
+	littleEndian.PutUint64(secret[32:], o.prime)
+	littleEndian.PutUint64(secret[40:], o.random)
+	return append(secret, o.key...)
+}
+
+// clone returns a copy of the primary scheme of o with the string form of o,
+// without the retired schemes.
+func (o *Obfuscator) clone() *Obfuscator {
+	p := o.primary()
+	return &Obfuscator{
+		prime:      p.prime,
+		modInverse: p.modInverse,
+		random:     p.random,
+		max:        p.max,
+		bits:       p.bits,
+		order:      o.order,
+		enc:        o.enc,
+		prefix:     o.prefix,
+		padded:     o.padded,
+		domain:     o.domain,
+		jsonNumber: o.jsonNumber,
+		minLen:     o.minLen,
+		padLen:     o.padLen,
+		zeroString: o.zeroString,
+		zeroSet:    o.zeroSet,
+		maxLen:     o.maxLen,
+		width:      o.width,
+		zeroSym:    o.zeroSym,
+		nonceBits:  p.nonceBits,
+		compact:    o.compact,
+		tag:        p.tag,
+		version:    p.version,
+		versionBit: p.versionBit,
+		seed:       p.seed,
+		seeded:     p.seeded,
+		seedBits:   p.seedBits,
+		pool:       p.pool,
+		primeRand:  p.primeRand,
+		mode:       p.mode,
+		perm:       p.perm,
+		rounds:     p.rounds,
+		key:        p.key,
+	}
+}
+
+// Obfuscate is used to encode id using Knuth's hashing algorithm, or the
+// permutation of the configured mode.
+// With WithNonce, the obfuscated value of an id is a different one each time.
+// With WithTagBit, an already obfuscated value is returned as it is.
+func (o *Obfuscator) Obfuscate(id uint64) uint64 {
+	p := o.primary()
+	if p.tagged(id) {
+		return id
+	}
+	return p.forward(p.withNonce(id))
+}
+
+// ObfuscateChecked is like Obfuscate, but it returns an error if id is out
+// of the id space. Obfuscate silently wraps such an id, which DeObfuscate can
+// then not recover.
+func (o *Obfuscator) ObfuscateChecked(id uint64) (uint64, error) {
+	if limit := o.limit(); id > limit {
+		if o.primary().tagged(id) {
+			return 0, newError(ErrOutOfRange, "id %d is already obfuscated", id)
+		}
+		return 0, newError(ErrOutOfRange, "id %d is out of range [0,%d]", id, limit)
+	}
+	return o.Obfuscate(id), nil
+}
+
+// Capacity returns the number of distinct ids of the scheme, the ids are in
+// the range [0,Capacity()-1]. It is 1<<53 by default, 1<<n WithBits(n), and the
+// bits reserved WithNonce are not available to ids. The 1<<64 ids of a 64 bits
+// scheme without nonce do not fit in a uint64, Capacity then returns 0 like
+// the modulus of ModInverse. The checksum of FormatIDWithCheck takes no bits.
+func (o *Obfuscator) Capacity() uint64 { return o.limit() + 1 }
+
+// DeObfuscate is used to decode n back to the original id.
+// It will only decode correctly if n was encoded by the same Obfuscator,
+// or by one of the schemes it was rotated from, see RotateTo.
+// The inverse is precomputed by NewObfuscator, so DeObfuscate is plain uint64
+// arithmetic and does not allocate.
+func (o *Obfuscator) DeObfuscate(n uint64) uint64 {
+	id, _ := o.deObfuscate(n)
+	return id
+}
+
+// ObfuscateSlice returns a new slice holding the obfuscated ids.
+func (o *Obfuscator) ObfuscateSlice(ids []uint64) []uint64 {
+	p := o.primary()
+	ns := make([]uint64, len(ids))
+	if p.perm != nil || p.nonceBits != 0 || p.tag != 0 || p.versionBit != 0 {
+		for i, id := range ids {
+			ns[i] = p.Obfuscate(id)
+		}
+		return ns
+	}
+	prime, max, random := p.prime, p.max, p.random
+	for i, id := range ids {
+		ns[i] = Permute(id, prime, max, random)
+	}
+	return ns
+}
+
+// DeObfuscateSlice is an inverse operation of ObfuscateSlice.
+func (o *Obfuscator) DeObfuscateSlice(ns []uint64) []uint64 {
+	ids := make([]uint64, len(ns))
+	if o.keys.Load() != nil || o.perm != nil || o.nonceBits != 0 || o.tag != 0 || o.versionBit != 0 {
+		for i, n := range ns {
+			ids[i] = o.DeObfuscate(n)
+		}
+		return ids
+	}
+	inverse, max, random := o.modInverse, o.max, o.random
+	for i, n := range ns {
+		ids[i] = Unpermute(n, inverse, max, random)
+	}
+	return ids
+}
+
+// limit returns the upper bound of the ids, that is max unless bits are
+// reserved for the nonce.
+func (o *Obfuscator) limit() uint64 { return o.max >> o.nonceBits }
+
+// withNonce returns id with a random nonce in the reserved upper bits, or id
+// itself without WithNonce.
+func (o *Obfuscator) withNonce(id uint64) uint64 {
+	if o.nonceBits == 0 {
+		return id
+	}
+	return id&o.limit() | rand.Uint64()>>(64-o.nonceBits)<<(o.bits-o.nonceBits)
+}
+
+// forward obfuscates id with the scheme of o itself, regardless of rotation.
+func (o *Obfuscator) forward(id uint64) uint64 {
+	if o.perm != nil {
+		return o.perm.forward(id) | o.tag | o.version
+	}
+	return Permute(id, o.prime, o.max, o.random) | o.tag | o.version
+}
+
+// backward is an inverse operation of forward.
+func (o *Obfuscator) backward(n uint64) uint64 {
+	n &^= o.tag | o.versionMask()
+	if o.perm != nil {
+		return o.perm.backward(n)
+	}
+	return Unpermute(n, o.modInverse, o.max, o.random)
+}
+
+// tagged reports whether n is an obfuscated value of a tagged scheme, see
+// WithTagBit.
+func (o *Obfuscator) tagged(n uint64) bool {
+	return o.tag != 0 && n&^(o.max|o.versionMask()) == o.tag
+}
+
+// versionMask returns the mask of the bits holding the version, see
+// WithVersion.
+func (o *Obfuscator) versionMask() uint64 { return (1<<o.versionBit - 1) << o.bits }
+
+// valueBits returns the width of the obfuscated values, the version and the
+// tag bit included.
+func (o *Obfuscator) valueBits() int {
+	if o.tag != 0 {
+		return o.bits + o.versionBit + 1
+	}
+	return o.bits + o.versionBit
+}
+
+// FormatID returns the obfuscated id in base64 string format, or in the
+// configured encoding, the 8 bytes of the obfuscated value are laid out in the
+// configured byte order.
+func (o *Obfuscator) FormatID(id ID) string {
+	if id == 0 && o.zeroSet {
+		return o.zeroString
+	}
+	return o.format(o.Obfuscate(id.Uint64()))
+}
+
+// format returns the string form of the obfuscated value n.
+func (o *Obfuscator) format(n uint64) string {
+	bp := bufPool.Get().(*[]byte)
+	out := o.appendValue((*bp)[:0], n)
+	s := string(out)
+	*bp = out
+	bufPool.Put(bp)
+	return s
+}
+
+// AppendString appends the string form of id, as returned by FormatID, to dst
+// and returns the extended buffer. Reusing dst saves the allocation of the
+// string.
+func (o *Obfuscator) AppendString(dst []byte, id ID) []byte {
+	if id == 0 && o.zeroSet {
+		return append(dst, o.zeroString...)
+	}
+	return o.appendValue(dst, o.Obfuscate(id.Uint64()))
+}
+
+// appendID is like AppendString, but it returns an error if id is out of
+// range instead of wrapping it, see ObfuscateChecked.
+func (o *Obfuscator) appendID(dst []byte, id uint64) ([]byte, error) {
+	n, err := o.ObfuscateChecked(id)
+	if err != nil {
+		return dst, err
+	}
+	if id == 0 && o.zeroSet {
+		return append(dst, o.zeroString...), nil
+	}
+	return o.appendValue(dst, n), nil
+}
+
+// WriteString writes the string form of id, as returned by FormatID, to w.
+// The string is encoded into a pooled buffer, so writing many ids does not
+// allocate a string per id.
+func (o *Obfuscator) WriteString(w io.Writer, id ID) (int, error) {
+	bp := bufPool.Get().(*[]byte)
+	buf := o.AppendString((*bp)[:0], id)
+	n, err := w.Write(buf)
+	*bp = buf
+	bufPool.Put(bp)
+	return n, err
+}
+
+// Sequence returns an iterator over the obfuscated strings of the count
+// consecutive ids from start, as returned by FormatID, e.g. to seed a test
+// database. The iteration stops early at the last id of the range of o, see
+// Capacity, instead of wrapping around.
+func (o *Obfuscator) Sequence(start, count uint64) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		limit := o.limit()
+		for i := uint64(0); i < count; i++ {
+			id := start + i
+			if id < start || id > limit || !yield(o.FormatID(ID(id))) {
+				return
+			}
+		}
+	}
+}
+
+// fixedLength reports whether the string forms of all the ids of o are of the
+// same length.
+func (o *Obfuscator) fixedLength() bool {
+	if o.compact {
+		return false
+	}
+	return o.width != 0 || len(o.enc.EncodeToString(make([]byte, 8+o.padLen))) ==
+		len(o.enc.EncodeToString(bytes.Repeat([]byte{0xff}, 8+o.padLen)))
+}
+
+// appendValue appends the string form of the obfuscated value n to dst.
+func (o *Obfuscator) appendValue(dst []byte, n uint64) []byte {
+	bp := bufPool.Get().(*[]byte)
+	buf := append((*bp)[:0], 0, 0, 0, 0, 0, 0, 0, 0)
+	o.order.PutUint64(buf, n)
+	if o.padLen > 0 {
+		buf = appendPadding(buf, o.padLen)
+	}
+	start := len(dst) + len(o.prefix)
+	if o.compact {
+		dst = o.appendEncode(dst, buf[min(bits.LeadingZeros64(n)/8, 7):])
+	} else {
+		dst = o.appendEncode(dst, buf)
+	}
+	if pad := o.width - (len(dst) - start); pad > 0 {
+		end := len(dst)
+		for i := 0; i < pad; i++ {
+			dst = append(dst, o.zeroSym)
+		}
+		copy(dst[start+pad:], dst[start:end])
+		for i := start; i < start+pad; i++ {
+			dst[i] = o.zeroSym
+		}
+	}
+	*bp = buf
+	bufPool.Put(bp)
+	return dst
+}
+
+// ParseID is an inverse operation of FormatID, returns zero if
+// any error occurs during parsing.
+//
+// ParseID accepts arbitrary input, e.g. pasted from a URL: it never panics and
+// it rejects strings longer than twice the longest string form before decoding
+// them. It may return an id for a string which FormatID does not produce, use
+// StrictParseID to reject those.
+func (o *Obfuscator) ParseID(s string) (ID, error) {
+	if o.cache != nil {
+		if id, ok := o.cache.get(s); ok {
+			return id, nil
+		}
+	}
+	n, err := o.parseValue(s)
+	if err != nil {
+		return 0, err
+	}
+	id := ID(o.DeObfuscate(n))
+	if o.cache != nil {
+		o.cache.add(s, id)
+	}
+	return id, nil
+}
+
+// ParseValue deobfuscates the obfuscated value n into an ID, it is the
+// counterpart of ParseID for values shipped as integers.
+func (o *Obfuscator) ParseValue(n uint64) ID { return ID(o.DeObfuscate(n)) }
+
+// StrictParseID is like ParseID, but it also re-obfuscates the decoded id and
+// returns an error unless it equals the obfuscated value held by s. A string
+// which was not produced by FormatID, e.g. a corrupted one, decodes to a value
+// outside of the id space and fails the check. An id returned without error
+// always round-trips: StrictParseID(FormatID(id)) returns the same id.
+func (o *Obfuscator) StrictParseID(s string) (ID, error) {
+	n, err := o.parseValue(s)
+	if err != nil {
+		return 0, err
+	}
+	id, ok := o.deObfuscate(n)
+	if !ok {
+		return 0, ErrOutOfRange
+	}
+	return ID(id), nil
+}
+
+// IsValidString reports whether s is a well-formed string of the Obfuscator:
+// it decodes to an 8 bytes value, the value deobfuscates to an id which
+// obfuscates back to it, and encoding the value returns exactly s. Unlike
+// StrictParseID, non canonical spellings, e.g. lower case Crockford, are
+// rejected. It does not allocate for the built-in encodings.
+func (o *Obfuscator) IsValidString(s string) bool { return o.validString(s, o.deObfuscate) }
+
+// Owns is like IsValidString, but only the primary scheme of o is tried, the
+// schemes o was rotated from are not, see RotateTo. It is meant to detect the
+// services of a fleet which run another scheme, e.g. the random default one.
+//
+// It is a heuristic: the permutations of the id space are bijections, so any
+// value in range deobfuscates to some id. A string of another scheme of the
+// same width, encoding, prefix and padding passes, only the upper bits left
+// zero by a narrower scheme, the prefix or the padding tell schemes apart.
+// Give each scheme its own prefix, see WithPrefix, for a reliable check.
+func (o *Obfuscator) Owns(s string) bool {
+	p := o.primary()
+	return o.validString(s, func(n uint64) (uint64, bool) {
+		id := p.backward(n)
+		return id & p.limit(), p.equal(p.forward(id), n)
+	})
+}
+
+// validString reports whether s is a well-formed string of the Obfuscator
+// whose value deobfuscates with deObfuscate.
+func (o *Obfuscator) validString(s string, deObfuscate func(uint64) (uint64, bool)) bool {
+	if o.zeroSet && s == o.zeroString {
+		return true
+	}
+	n, err := o.decodeValue(s)
+	if err != nil {
+		return false
+	}
+	if id, ok := deObfuscate(n); !ok || id == 0 && o.zeroSet {
+		return false
+	}
+	bp := bufPool.Get().(*[]byte)
+	buf := o.appendValue((*bp)[:0], n)
+	ok := string(buf) == s
+	*bp = buf
+	bufPool.Put(bp)
+	return ok
+}
+
+// parseValue decodes s into the obfuscated value.
+func (o *Obfuscator) parseValue(s string) (uint64, error) {
+	if o.zeroSet && s == o.zeroString {
+		return o.Obfuscate(0), nil
+	}
+	return o.decodeValue(s)
+}
+
+// decodeValue decodes the encoded form s into the obfuscated value.
+func (o *Obfuscator) decodeValue(s string) (uint64, error) {
+	bp := bufPool.Get().(*[]byte)
+	defer bufPool.Put(bp)
+	buf, err := o.appendDecode((*bp)[:0], s)
+	*bp = buf[:0]
+	if err != nil {
+		return 0, err
+	}
+	return o.checkValue(buf)
+}
+
+// checkValue returns the obfuscated value of the decoded bytes buf, checking
+// its length and its padding.
+func (o *Obfuscator) checkValue(buf []byte) (uint64, error) {
+	if o.compact {
+		if len(buf) < 1 || len(buf) > 8 {
+			return 0, newError(ErrInvalidLength, "unexpected id format")
+		}
+		var full [8]byte
+		copy(full[8-len(buf):], buf)
+		return binary.BigEndian.Uint64(full[:]), nil
+	}
+	// The zero symbols padding a fixed width id decode to leading zero bytes.
+	for o.width != 0 && len(buf) > 8+o.padLen && buf[0] == 0 {
+		buf = buf[1:]
+	}
+	if len(buf) != 8+o.padLen { // ID expected to be exactly 8 bytes.
+		return 0, newError(ErrInvalidLength, "unexpected id format")
+	}
+	if o.padLen > 0 && !validPadding(buf, o.padLen) {
+		return 0, newError(ErrInvalidFormat, "unexpected id padding")
+	}
+	return o.order.Uint64(buf), nil
+}
+
+// bufPool holds scratch buffers for the bytes and the string form of ids.
+var bufPool = sync.Pool{New: func() any {
+	buf := make([]byte, 0, 64)
+	return &buf
+}}
+
+// encode returns the string form of buf: the prefix followed by buf in the
+// configured encoding.
+func (o *Obfuscator) encode(buf []byte) string { return string(o.appendEncode(nil, buf)) }
+
+// appendEncode appends the string form of buf to dst.
+func (o *Obfuscator) appendEncode(dst, buf []byte) []byte {
+	dst = append(dst, o.prefix...)
+	if e, ok := o.enc.(appendEncoding); ok {
+		return e.AppendEncode(dst, buf)
+	}
+	return append(dst, o.enc.EncodeToString(buf)...)
+}
+
+// decode is an inverse operation of encode.
+func (o *Obfuscator) decode(s string) ([]byte, error) { return o.appendDecode(nil, s) }
+
+// appendDecode appends the bytes decoded from the string form s to dst.
+func (o *Obfuscator) appendDecode(dst []byte, s string) ([]byte, error) {
+	return o.appendDecodeMax(dst, s, o.maxLen)
+}
+
+// appendDecodeMax is like appendDecode for strings of at most maxLen
+// characters, e.g. the strings of FormatIDWithTTL which hold more than an id.
+func (o *Obfuscator) appendDecodeMax(dst []byte, s string, maxLen int) ([]byte, error) {
+	if len(s) > maxLen {
+		return dst, newError(ErrInvalidLength, "fails to decode id: longer than %d characters", maxLen)
+	}
+	if !strings.HasPrefix(s, o.prefix) {
+		return dst, newError(ErrInvalidFormat, "id expected to start with %q", o.prefix)
+	}
+	s = s[len(o.prefix):]
+	var err error
+	if e, ok := o.enc.(appendEncoding); ok {
+		bp := bufPool.Get().(*[]byte)
+		src := append((*bp)[:0], s...)
+		dst, err = e.AppendDecode(dst, src)
+		*bp = src
+		bufPool.Put(bp)
+	} else {
+		var buf []byte
+		buf, err = o.enc.DecodeString(s)
+		dst = append(dst, buf...)
+	}
+	if err != nil {
+		return dst, newError(ErrInvalidFormat, "fails to decode id: %w", err)
+	}
+	return dst, nil
+}
+
+// binary returns the 8 bytes of the obfuscated value n in the configured
+// byte order.
+func (o *Obfuscator) binary(n uint64) []byte {
+	buf := make([]byte, 8)
+	o.order.PutUint64(buf, n)
+	return buf
+}
+
+// parseBinary is an inverse operation of binary.
+func (o *Obfuscator) parseBinary(buf []byte) (ID, error) {
+	if len(buf) != 8 { // ID expected to be exactly 8 bytes.
+		return 0, newError(ErrInvalidLength, "unexpected id format")
+	}
+	return ID(o.DeObfuscate(o.order.Uint64(buf))), nil
+}
+
+// validPadding reports whether the bytes following the first 8 of buf are
+// the n padding bytes appended by appendPadding.
+func validPadding(buf []byte, n int) bool {
+	var pad [8 + 256]byte
+	return bytes.Equal(appendPadding(append(pad[:0], buf[:8]...), n), buf)
+}
+
+// appendPadding appends n padding bytes to the 8 bytes of buf. The padding is
+// derived from buf, so that it is deterministic but does not look constant.
+func appendPadding(buf []byte, n int) []byte {
+	x := binary.LittleEndian.Uint64(buf)
+	for i := 0; i < n; i++ {
+		// splitmix64 step.
+		x += 0x9e3779b97f4a7c15
+		z := (x ^ x>>30) * 0xbf58476d1ce4e5b9
+		z = (z ^ z>>27) * 0x94d049bb133111eb
+		buf = append(buf, byte(z^z>>31))
+	}
+	return buf
+}
+
+// defaultObfuscator returns the default Obfuscator, creating it on first use.
+// The error of the creation is kept and returned by every later call.
+func defaultObfuscator() (*Obfuscator, error) {
+	if o := defaultObf.Load(); o != nil {
+		return o, nil
+	}
+	defaultOnce.Do(func() {
+		o, err := NewObfuscator()
+		if err != nil {
+			defaultErr = err
+			return
+		}
+		// SetDefault may have been called meanwhile, it wins.
+		defaultObf.CompareAndSwap(nil, o)
+	})
+	if o := defaultObf.Load(); o != nil {
+		return o, nil
+	}
+	return nil, defaultErr
+}
+
+// SetDefault installs o as the default Obfuscator used by the package level
+// functions and by the methods of ID, e.g. a seeded one in TestMain or at
+// startup. When it is called before their first use the random default scheme
+// is never created.
+//
+// It is safe to call concurrently and may be called after the default has
+// been used, but ids produced by the previous default can not be decoded by o
+// and goroutines may observe either scheme while they race with SetDefault.
+// Configure the default once at startup. o must not be nil.
+func SetDefault(o *Obfuscator) {
+	if o != nil {
+		defaultObf.Store(o)
+	}
+}
+
+// Default returns the default Obfuscator, either the one installed by
+// SetDefault or the random one created on first use. It panics if the latter
+// could not be created.
+func Default() *Obfuscator { return mustDefault() }
+
+// mustDefault is like defaultObfuscator but panics if the default Obfuscator
+// could not be created. It is used by the functions which can not return an
+// error, those which can should use defaultObfuscator instead.
+func mustDefault() *Obfuscator {
+	o, err := defaultObfuscator()
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+// Permute is Knuth's multiplicative hashing, the core of the Multiplicative
+// mode, usable without an Obfuscator: v is multiplied by prime, reduced by
+// mask, which must be 1<<n - 1, and xored with xor. It is a bijection of
+// [0,mask] as long as prime is odd and xor is at most mask, Unpermute is its
+// inverse:
+//
+//	const mask = 1<<53 - 1
+//	inv, _ := ModInverse(prime, mask+1)
+//	n := Permute(v, prime, mask, xor)
+//	v == Unpermute(n, inv, mask, xor) // true for any v <= mask
+func Permute(v, prime, mask, xor uint64) uint64 { return ((v * prime) & mask) ^ xor }
+
+// Unpermute is an inverse operation of Permute, invPrime is the modular
+// inverse of the prime modulo mask+1, see ModInverse.
+func Unpermute(v, invPrime, mask, xor uint64) uint64 { return ((v ^ xor) * invPrime) & mask }
+
+// ModInverse returns the modular inverse of value modulo modulus, such that
+// (value * inverse) % modulus == 1. A modulus of 0 stands for 1<<64, the
+// modulus of a 64 bits wide scheme. It returns an error if value has no
+// inverse, i.e. value and modulus are not coprime.
+// It is backed by math/big, NewObfuscator calls it once per scheme and keeps
+// the result, it is never called to obfuscate or deobfuscate an id.
+//
+// See: http://en.wikipedia.org/wiki/Modular_multiplicative_inverse
+func ModInverse(value, modulus uint64) (uint64, error) {
+	m := (&big.Int{}).SetUint64(modulus)
+	if modulus == 0 {
+		m.Lsh(big.NewInt(1), 64)
+	}
+	inv := (&big.Int{}).ModInverse((&big.Int{}).SetUint64(value), m)
+	if inv == nil {
+		return 0, fmt.Errorf("%d has no inverse modulo %s", value, m)
+	}
+	return inv.Uint64(), nil
+}
+
+// randPrime returns the first prime following a random point of the upper half
+// of the bits wide id space read from r, wrapping around within the half.
+func randPrime(r io.Reader, bits int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, fmt.Errorf("fails to read prime: %w", err)
+	}
+	top := uint64(1) << (bits - 1)
+	half := top - 1
+	for p := littleEndian.Uint64(buf[:])&half | top | 1; ; p = (p+2)&half | top {
+		if isPrime(p) {
+			return p, nil
+		}
+	}
+}
+
+// isPrime reports whether p is probably a prime.
+// See: https://golang.org/pkg/math/big/#Int.ProbablyPrime
+func isPrime(p uint64) bool { return (&big.Int{}).SetUint64(p).ProbablyPrime(MillerRabin) }
+
+// accuracy returns the accuracy of isPrime, 1 - 1/4^MillerRabin.
+func accuracy() float64 { return 1.0 - 1.0/math.Pow(float64(4), float64(MillerRabin)) }
+
+// PrimeReport is the diagnostic of a prime returned by ValidatePrime.
+type PrimeReport struct {
+	Prime    bool    // the prime passes the Miller-Rabin test, see MillerRabin.
+	Accuracy float64 // probability of the test to be right, 1 - 1/4^MillerRabin.
+	Coprime  bool    // the prime is coprime with 1<<bits, it has a modular inverse.
+}
+
+// Valid reports whether the prime can be used by a scheme, see WithPrime.
+func (r PrimeReport) Valid() bool { return r.Prime && r.Coprime }
+
+// ValidatePrime returns the diagnostic of p as the prime of a bits wide
+// scheme, to be checked before passing p to WithPrime. An odd number is
+// coprime with 1<<bits, the requirement of ModInverse, but it does not need to
+// be a prime for it. It returns an error if bits is out of the range
+// [MinBits,MaxBits].
+func ValidatePrime(p uint64, bits int) (PrimeReport, error) {
+	if bits < MinBits || bits > MaxBits {
+		return PrimeReport{}, fmt.Errorf("bits %d is out of range [%d,%d]", bits, MinBits, MaxBits)
+	}
+	return PrimeReport{Prime: isPrime(p), Accuracy: accuracy(), Coprime: p&1 == 1}, nil
+}
+
+// randN returns a cryptographically secure random number
+// in the range [1,N].
+func randN(N uint64) uint64 {
+	n, _ := crand.Int(crand.Reader, (&big.Int{}).SetUint64(N))
+	in := n.Uint64() + 1
+	return in
+}
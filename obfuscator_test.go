@@ -0,0 +1,720 @@
+package goobfuscated
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestByteOrder(t *testing.T) {
+	little, err := NewObfuscator(WithSeed(20200101), WithByteOrder(binary.LittleEndian))
+	if err != nil {
+		t.Fatal(err)
+	}
+	big, err := NewObfuscator(WithSeed(20200101), WithByteOrder(binary.BigEndian))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name       string
+		enc, parse *Obfuscator
+	}{
+		{"big-endian string, little-endian parser", big, little},
+		{"little-endian string, big-endian parser", little, big},
+	} {
+		for id := ID(1); id <= 100; id++ {
+			s := tt.enc.FormatID(id)
+			if got, err := tt.enc.StrictParseID(s); err != nil || got != id {
+				t.Fatalf("%s: %q parses to %d, %v by its own parser, want %d", tt.name, s, got, err, id)
+			}
+			if got, err := tt.parse.StrictParseID(s); err == nil {
+				t.Errorf("%s: %q of %d parses to %d", tt.name, s, id, got)
+			}
+		}
+	}
+}
+
+func TestObfuscateSlice(t *testing.T) {
+	o := newBenchObfuscator(t)
+	ids := []uint64{0, 1, 2, 100, MaxInt}
+	ns := o.ObfuscateSlice(ids)
+	for i, id := range ids {
+		if ns[i] != o.Obfuscate(id) {
+			t.Errorf("ObfuscateSlice()[%d] = %d, want %d", i, ns[i], o.Obfuscate(id))
+		}
+	}
+	for i, id := range o.DeObfuscateSlice(ns) {
+		if id != ids[i] {
+			t.Errorf("DeObfuscateSlice()[%d] = %d, want %d", i, id, ids[i])
+		}
+	}
+}
+
+// benchIDs are the ids of the batch benchmarks.
+var benchIDs = func() []uint64 {
+	ids := make([]uint64, 1024)
+	for i := range ids {
+		ids[i] = uint64(i) * 7919
+	}
+	return ids
+}()
+
+func BenchmarkObfuscateSlice(b *testing.B) {
+	o := newBenchObfuscator(b)
+	for range b.N {
+		_ = o.ObfuscateSlice(benchIDs)
+	}
+}
+
+func BenchmarkObfuscateLoop(b *testing.B) {
+	o := newBenchObfuscator(b)
+	for range b.N {
+		ns := make([]uint64, len(benchIDs))
+		for i, id := range benchIDs {
+			ns[i] = o.Obfuscate(id)
+		}
+	}
+}
+
+func BenchmarkDeObfuscateSlice(b *testing.B) {
+	o := newBenchObfuscator(b)
+	ns := o.ObfuscateSlice(benchIDs)
+	b.ResetTimer()
+	for range b.N {
+		_ = o.DeObfuscateSlice(ns)
+	}
+}
+
+func BenchmarkDeObfuscateLoop(b *testing.B) {
+	o := newBenchObfuscator(b)
+	ns := o.ObfuscateSlice(benchIDs)
+	b.ResetTimer()
+	for range b.N {
+		ids := make([]uint64, len(ns))
+		for i, n := range ns {
+			ids[i] = o.DeObfuscate(n)
+		}
+	}
+}
+
+func TestMinLength(t *testing.T) {
+	for _, bits := range []int{8, 16, 53} {
+		for _, n := range []int{0, 11, 12, 16, 22} {
+			o, err := NewObfuscator(WithSeed(20200101), WithBits(bits), WithMinLength(n))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for id := ID(0); id < 256; id++ {
+				s := o.FormatID(id)
+				if len(s) < n {
+					t.Fatalf("bits %d, min length %d: %q of %d is %d characters long", bits, n, s, id, len(s))
+				}
+				if again := o.FormatID(id); again != s {
+					t.Fatalf("bits %d, min length %d: %d formats to %q then %q", bits, n, id, s, again)
+				}
+				if got, err := o.StrictParseID(s); err != nil || got != id {
+					t.Fatalf("bits %d, min length %d: %q parses to %d, %v, want %d", bits, n, s, got, err, id)
+				}
+			}
+		}
+	}
+}
+
+// resetDefault forgets the default Obfuscator, as if it was never used, and
+// restores it at the end of the test.
+func resetDefault(tb testing.TB) {
+	tb.Helper()
+	prev := defaultObf.Load()
+	defaultObf.Store(nil)
+	defaultOnce, defaultErr = sync.Once{}, nil
+	tb.Cleanup(func() { defaultObf.Store(prev) })
+}
+
+// TestDefaultConcurrentFirstUse is meant to be run with -race: the goroutines
+// racing to first use the default must all get the same scheme.
+func TestDefaultConcurrentFirstUse(t *testing.T) {
+	resetDefault(t)
+	const n = 16
+	var wg sync.WaitGroup
+	got := make([]*Obfuscator, n)
+	values := make([]uint64, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i] = Obfuscate(100)
+			got[i] = Default()
+		}()
+	}
+	wg.Wait()
+	for i := range n {
+		if got[i] != got[0] || values[i] != values[0] {
+			t.Fatalf("goroutine %d uses another default scheme", i)
+		}
+	}
+}
+
+func TestSetDefaultBeforeFirstUse(t *testing.T) {
+	resetDefault(t)
+	o := newBenchObfuscator(t)
+	SetDefault(o)
+	if Default() != o {
+		t.Fatal("Default does not return the Obfuscator of SetDefault")
+	}
+	if got, want := Obfuscate(100), o.Obfuscate(100); got != want {
+		t.Errorf("Obfuscate(100) = %d, want %d", got, want)
+	}
+}
+
+func TestAppendString(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithPrefix("usr_"), WithMinLength(22)},
+		{WithEncoding(Crockford), WithFixedWidth()},
+	} {
+		o, err := NewObfuscator(append([]Option{WithSeed(20200101)}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 0, 64)
+		for _, id := range []ID{0, 1, 100, MaxInt} {
+			buf = append(buf[:0], "id="...)
+			buf = o.AppendString(buf, id)
+			if got, want := string(buf), "id="+o.FormatID(id); got != want {
+				t.Errorf("AppendString(%d) appends %q, want %q", id, got, want)
+			}
+		}
+		// The bytes of the id are encoded in a pooled buffer, only the
+		// returned string of FormatID allocates.
+		if raceEnabled {
+			continue
+		}
+		if n := testing.AllocsPerRun(100, func() { buf = o.AppendString(buf[:0], 100) }); n != 0 {
+			t.Errorf("AppendString allocates %v times", n)
+		}
+		if n := testing.AllocsPerRun(100, func() { _ = o.FormatID(100) }); n != 1 {
+			t.Errorf("FormatID allocates %v times, want 1", n)
+		}
+	}
+}
+
+func BenchmarkAppendStringPadded(b *testing.B) {
+	o, err := NewObfuscator(WithSeed(20200101), WithPrefix("usr_"), WithMinLength(22))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	buf := make([]byte, 0, 64)
+	for i := range b.N {
+		buf = o.AppendString(buf[:0], ID(i))
+	}
+}
+
+// TestDeObfuscateAllocs checks that decoding stays off math/big: the inverse
+// is precomputed, DeObfuscate allocates nothing whatever the width.
+func TestDeObfuscateAllocs(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithBits(8)},
+		{WithBits(64)},
+		{WithNonce(8)},
+		{WithTagBit()},
+		{WithFeistel(8, []byte("feistel key"))},
+		{WithMode(Sortable)},
+	} {
+		o, err := NewObfuscator(append([]Option{WithSeed(20200101)}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := o.Obfuscate(100)
+		if got := testing.AllocsPerRun(100, func() { o.DeObfuscate(n) }); got != 0 {
+			t.Errorf("DeObfuscate of %s allocates %v times", modeNames[o.primary().mode], got)
+		}
+	}
+	o := newBenchObfuscator(t)
+	next, err := NewObfuscator(WithSeed(20200102), WithMode(Sortable))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.RotateTo(next); err != nil {
+		t.Fatal(err)
+	}
+	n := o.Obfuscate(100)
+	if got := testing.AllocsPerRun(100, func() { o.DeObfuscate(n) }); got != 0 {
+		t.Errorf("DeObfuscate of a rotated scheme allocates %v times", got)
+	}
+}
+
+func BenchmarkDeObfuscate64(b *testing.B) {
+	o, err := NewObfuscator(WithSeed(20200101), WithBits(64))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	var n uint64
+	for i := range uint64(b.N) {
+		n += o.DeObfuscate(i)
+	}
+	_ = n
+}
+
+func TestWriteString(t *testing.T) {
+	o := newBenchObfuscator(t)
+	var b bytes.Buffer
+	for _, id := range []ID{0, 1, 100, MaxInt} {
+		b.Reset()
+		if n, err := o.WriteString(&b, id); err != nil || n != b.Len() {
+			t.Fatalf("WriteString(%d) returns %d, %v, wrote %d bytes", id, n, err, b.Len())
+		}
+		if got, want := b.String(), o.FormatID(id); got != want {
+			t.Errorf("WriteString(%d) writes %q, want %q", id, got, want)
+		}
+	}
+}
+
+// The streaming benchmarks write 1024 ids, comma separated, to a reused
+// buffer: with WriteString, with AppendString and with a String per id.
+
+func BenchmarkStreamWriteString(b *testing.B) {
+	o := newBenchObfuscator(b)
+	var w bytes.Buffer
+	b.ReportAllocs()
+	for range b.N {
+		w.Reset()
+		for _, id := range benchIDs {
+			o.WriteString(&w, ID(id))
+			w.WriteByte(',')
+		}
+	}
+}
+
+func BenchmarkStreamAppendString(b *testing.B) {
+	o := newBenchObfuscator(b)
+	var buf []byte
+	b.ReportAllocs()
+	for range b.N {
+		buf = buf[:0]
+		for _, id := range benchIDs {
+			buf = o.AppendString(buf, ID(id))
+			buf = append(buf, ',')
+		}
+	}
+}
+
+func BenchmarkStreamString(b *testing.B) {
+	o := newBenchObfuscator(b)
+	var w bytes.Buffer
+	b.ReportAllocs()
+	for range b.N {
+		w.Reset()
+		for _, id := range benchIDs {
+			w.WriteString(o.FormatID(ID(id)))
+			w.WriteByte(',')
+		}
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	o := newBenchObfuscator(t)
+	if got := o.Capacity(); got != o.max+1 || got != MaxInt+1 {
+		t.Errorf("Capacity() = %d, want the mask %d + 1", got, o.max)
+	}
+	for _, tt := range []struct {
+		opts []Option
+		want uint64
+	}{
+		{[]Option{WithBits(8)}, 1 << 8},
+		{[]Option{WithBits(64)}, 0},
+		{[]Option{WithNonce(8)}, 1 << 45},
+		{[]Option{WithTagBit()}, 1 << 52},
+	} {
+		o, err := NewObfuscator(append([]Option{WithSeed(20200101)}, tt.opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := o.Capacity(); got != tt.want {
+			t.Errorf("Capacity() = %d, want %d", got, tt.want)
+		}
+	}
+}
+
+func TestSeedPrime(t *testing.T) {
+	t.Setenv("GOOBFUSCATED_TEST_SEED", "a seed of any length")
+	for _, opt := range []Option{WithSeed(20200101), WithSeedFromEnv("GOOBFUSCATED_TEST_SEED")} {
+		a, err := NewObfuscator(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := NewObfuscator(opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.prime != b.prime || a.random != b.random {
+			t.Errorf("the same seed gives the primes %d and %d, the masks %d and %d", a.prime, b.prime, a.random, b.random)
+		}
+	}
+	primes := make(map[uint64]bool)
+	for seed := range int64(16) {
+		o, err := NewObfuscator(WithSeed(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		primes[o.prime] = true
+	}
+	if len(primes) < 8 {
+		t.Errorf("16 seeds give %d primes", len(primes))
+	}
+	a, b := newUnseeded(t), newUnseeded(t)
+	if a.prime == b.prime && a.random == b.random {
+		t.Error("two unseeded schemes are the same")
+	}
+}
+
+// TestSeedGolden pins the schemes of two seeds: a seed must derive the same
+// scheme on every run and on every machine, on which the stored ids rely.
+func TestSeedGolden(t *testing.T) {
+	for _, tt := range []struct {
+		seed        int64
+		prime, mask uint64
+		value       uint64
+		str         string
+	}{
+		{0, 452981747, 8244047506464568, 8244063272458982, "5lrcju5JHQA"},
+		{20200101, 452981689, 2941140427859529, 2941159036033555, "ExqS8_dyCgA"},
+	} {
+		o, err := NewObfuscator(WithSeed(tt.seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if o.prime != tt.prime || o.random != tt.mask {
+			t.Errorf("seed %d gives the prime %d and the mask %d, want %d and %d", tt.seed, o.prime, o.random, tt.prime, tt.mask)
+		}
+		if got := o.Obfuscate(42); got != tt.value {
+			t.Errorf("seed %d: Obfuscate(42) = %d, want %d", tt.seed, got, tt.value)
+		}
+		if got := o.FormatID(42); got != tt.str {
+			t.Errorf("seed %d: FormatID(42) = %q, want %q", tt.seed, got, tt.str)
+		}
+	}
+}
+
+func newUnseeded(t *testing.T) *Obfuscator {
+	t.Helper()
+	o, err := NewObfuscator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestDomainSalt(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"multiplicative": nil,
+		"feistel":        {WithFeistel(8, []byte("feistel key"))},
+		"keyed":          {WithMode(Keyed), WithKey([]byte("keyed key"))},
+		"random prime":   {WithRandomPrime(bytes.NewReader(make([]byte, 64)))},
+		"mask":           {WithMask(12345)},
+	} {
+		domain := func(d string) *Obfuscator {
+			t.Helper()
+			o, err := NewObfuscator(append([]Option{WithSeed(20200101), WithDomainSalt(d)}, opts...)...)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			return o
+		}
+		user, order := domain("user"), domain("order")
+		if again := domain("user"); again.prime != user.prime || again.random != user.random || !bytes.Equal(again.key, user.key) {
+			t.Errorf("%s: the same seed and domain give two schemes", name)
+		}
+		if user.random == 0 || order.random == 0 {
+			t.Errorf("%s: a domain gives a zero mask", name)
+		}
+		if user.mode != Multiplicative && bytes.Equal(user.key, order.key) {
+			t.Errorf("%s: two domains share the key %x", name, user.key)
+		}
+		xors := make(map[uint64]bool)
+		for id := uint64(1); id <= 100; id++ {
+			u, o := user.Obfuscate(id), order.Obfuscate(id)
+			if u == o {
+				t.Errorf("%s: %d obfuscates to %d in both domains", name, id, u)
+			}
+			if got := user.DeObfuscate(u); got != id {
+				t.Errorf("%s: %d of the user domain de-obfuscates to %d, want %d", name, u, got, id)
+			}
+			xors[u^o] = true
+		}
+		if len(xors) < 90 {
+			t.Errorf("%s: the values of two domains xor to %d values for 100 ids", name, len(xors))
+		}
+	}
+}
+
+func TestBits(t *testing.T) {
+	for _, tt := range []struct {
+		bits int
+		max  uint64
+	}{
+		{8, 1<<8 - 1},
+		{32, 1<<32 - 1},
+		{48, 1<<48 - 1},
+		{53, MaxInt},
+		{64, math.MaxUint64},
+	} {
+		o, err := NewObfuscator(WithSeed(20200101), WithBits(tt.bits))
+		if err != nil {
+			t.Fatalf("%d bits: %v", tt.bits, err)
+		}
+		if got := o.limit(); got != tt.max {
+			t.Errorf("%d bits: the upper bound is %d, want %d", tt.bits, got, tt.max)
+		}
+		for _, id := range []uint64{0, 1, tt.max - 1, tt.max} {
+			n, err := o.ObfuscateChecked(id)
+			if err != nil {
+				t.Errorf("%d bits: ObfuscateChecked(%d): %v", tt.bits, id, err)
+			}
+			if n > tt.max {
+				t.Errorf("%d bits: %d obfuscates to %d, above %d", tt.bits, id, n, tt.max)
+			}
+			if got := o.DeObfuscate(n); got != id {
+				t.Errorf("%d bits: %d de-obfuscates to %d, want %d", tt.bits, n, got, id)
+			}
+			s := o.FormatID(ID(id))
+			if got, err := o.StrictParseID(s); err != nil || got != ID(id) {
+				t.Errorf("%d bits: %q parses to %d, %v, want %d", tt.bits, s, got, err, id)
+			}
+		}
+		if tt.max != math.MaxUint64 {
+			if _, err := o.ObfuscateChecked(tt.max + 1); !errors.Is(err, ErrOutOfRange) {
+				t.Errorf("%d bits: ObfuscateChecked(%d) returns %v, want ErrOutOfRange", tt.bits, tt.max+1, err)
+			}
+		}
+	}
+	for _, bits := range []int{MinBits - 1, MaxBits + 1} {
+		if _, err := NewObfuscator(WithBits(bits)); err == nil {
+			t.Errorf("WithBits(%d) is accepted", bits)
+		}
+	}
+}
+
+// TestCheckMistyped checks that the checksum of FormatIDWithCheck rejects
+// every change of a single character of the encodings which map each
+// character to a fixed group of bits, and every dropped character.
+func TestCheckMistyped(t *testing.T) {
+	for name, enc := range map[string]Encoding{"base64": Base64, "crockford": Crockford, "hex": Hex} {
+		o, err := NewObfuscator(WithSeed(20200101), WithEncoding(enc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []ID{0, 1, 100, MaxInt} {
+			s := o.FormatIDWithCheck(id)
+			if got, err := o.ParseIDWithCheck(s); err != nil || got != id {
+				t.Fatalf("%s: %q parses to %d, %v, want %d", name, s, got, err, id)
+			}
+			want, _ := o.decode(s)
+			for i := range len(s) {
+				if _, err := o.ParseIDWithCheck(s[:i] + s[i+1:]); err == nil {
+					t.Errorf("%s: %q without its character %d passes the check", name, s, i)
+				}
+				for c := byte('!'); c <= '~'; c++ {
+					typo := s[:i] + string(c) + s[i+1:]
+					// The aliases, e.g. of the case insensitive encodings,
+					// and the unused bits of the last character are no change.
+					if buf, err := o.decode(typo); err != nil || bytes.Equal(buf, want) {
+						continue
+					}
+					if got, err := o.ParseIDWithCheck(typo); err == nil {
+						t.Errorf("%s: %q mistyped as %q passes the check as %d", name, s, typo, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestTTL checks the window in which a string of FormatIDWithTTL parses: from
+// the bucket before the one it was formatted in, the clock of the parser may
+// lag by up to ttl, to the end of the bucket following it.
+func TestTTL(t *testing.T) {
+	o := newBenchObfuscator(t)
+	const ttl = time.Hour
+	start := time.Unix(0, 0).Add(1000 * ttl) // the start of the bucket 1000.
+	s := o.formatIDWithTTL(100, ttl, start.Add(ttl/2))
+	for _, tt := range []struct {
+		name string
+		at   time.Duration // since start.
+		err  error
+	}{
+		{"same bucket", 0, nil},
+		{"next bucket", ttl, nil},
+		{"end of the next bucket", 2*ttl - 1, nil},
+		{"expired", 2 * ttl, ErrExpired},
+		{"long expired", 100 * ttl, ErrExpired},
+		{"clock lagging by a bucket", -ttl, nil},
+		{"clock lagging by more than a bucket", -ttl - 1, ErrExpired},
+	} {
+		id, err := o.parseIDWithTTL(s, ttl, start.Add(tt.at))
+		switch {
+		case tt.err == nil && (err != nil || id != 100):
+			t.Errorf("%s: %q parses to %d, %v, want 100", tt.name, s, id, err)
+		case tt.err != nil && !errors.Is(err, tt.err):
+			t.Errorf("%s: %q parses to %d, %v, want %v", tt.name, s, id, err, tt.err)
+		}
+	}
+	if _, err := o.parseIDWithTTL(s, 2*ttl, start); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("%q parses with another ttl, %v", s, err)
+	}
+	if _, err := o.parseIDWithTTL(s, 0, start); err == nil {
+		t.Errorf("%q parses with a zero ttl", s)
+	}
+	if s == o.formatIDWithTTL(100, ttl, start.Add(ttl)) {
+		t.Errorf("100 formats to %q in two buckets", s)
+	}
+}
+
+// TestEqual checks the comparison of the check of StrictParseID, constant
+// time in the keyed modes and plain in the others.
+func TestEqual(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		opts  []Option
+		keyed bool
+	}{
+		{"multiplicative", nil, false},
+		{"feistel", []Option{WithFeistel(8, []byte("feistel key"))}, true},
+		{"keyed", []Option{WithMode(Keyed), WithKey([]byte("keyed key"))}, true},
+	} {
+		o, err := NewObfuscator(append([]Option{WithSeed(20200101), WithBits(64)}, tt.opts...)...)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if o.keyed() != tt.keyed {
+			t.Errorf("%s: keyed() = %t, want %t", tt.name, o.keyed(), tt.keyed)
+		}
+		for _, c := range []struct {
+			a, b uint64
+			want bool
+		}{
+			{0, 0, true},
+			{math.MaxUint64, math.MaxUint64, true},
+			{0x0123456789abcdef, 0x0123456789abcdef, true},
+			{0, 1, false},
+			{0, 1 << 31, false},
+			{0, 1 << 32, false}, // only the upper half differs.
+			{0, 1 << 63, false},
+			{0x0123456789abcdef, 0x0123456789abcdee, false},
+			{1<<32 | 1, 0, false},
+		} {
+			if got := o.equal(c.a, c.b); got != c.want {
+				t.Errorf("%s: equal(%#x, %#x) = %t, want %t", tt.name, c.a, c.b, got, c.want)
+			}
+		}
+		for _, c := range []struct {
+			a, b byte
+			want bool
+		}{{0, 0, true}, {0xa5, 0xa5, true}, {0, 1, false}, {0x80, 0, false}} {
+			if got := o.checksumEqual(c.a, c.b); got != c.want {
+				t.Errorf("%s: checksumEqual(%#x, %#x) = %t, want %t", tt.name, c.a, c.b, got, c.want)
+			}
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := newBenchObfuscator(t).Config()
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("the config of an Obfuscator is invalid: %v", err)
+	}
+	for _, tt := range []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{"no prime", func(c *Config) { c.Prime = 0 }, []string{"no prime"}},
+		{"not a prime", func(c *Config) { c.Prime = 452981689 * 3 }, []string{"not a valid prime"}},
+		{"even prime", func(c *Config) { c.Prime = 2 }, []string{"not coprime"}},
+		{"zero mask", func(c *Config) { c.Mask = 0 }, []string{"mask 0 is out of range"}},
+		{"mask out of range", func(c *Config) { c.Mask = MaxInt + 1 }, []string{"out of range [1,9007199254740991]"}},
+		{"mask of the tag bit", func(c *Config) { c.Mask, c.TagBit = 1<<52, true }, []string{"out of range [1,4503599627370495]"}},
+		{"mask of the version", func(c *Config) { c.Version, c.VersionBit = 1, 4 }, []string{"out of range [1,562949953421311]"}},
+		{"bits too small", func(c *Config) { c.Bits = MinBits - 1 }, []string{"bits 7 is out of range"}},
+		{"bits too large", func(c *Config) { c.Bits = MaxBits + 1 }, []string{"bits 65 is out of range"}},
+		{"tag bit of the min width", func(c *Config) { c.Bits, c.Mask, c.TagBit = MinBits, 1, true }, []string{"tag bit leaves"}},
+		{"byte order", func(c *Config) { c.ByteOrder = "MiddleEndian" }, []string{`unknown byte order "MiddleEndian"`}},
+		{"encoding", func(c *Config) { c.Encoding = "base99" }, []string{`unknown encoding "base99"`}},
+		{"every problem", func(c *Config) { c.Prime, c.Bits, c.ByteOrder, c.Encoding = 0, 0, "", "base99" },
+			[]string{"no prime", "bits 0", "unknown byte order", "unknown encoding"}},
+	} {
+		c := valid
+		tt.change(&c)
+		err := c.Validate()
+		if err == nil {
+			t.Errorf("%s: %+v is valid", tt.name, c)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: Validate() = %q, want %q", tt.name, err, want)
+			}
+		}
+		if errs := err.(interface{ Unwrap() []error }).Unwrap(); len(errs) != len(tt.want) {
+			t.Errorf("%s: Validate() returns %d errors, want %d: %v", tt.name, len(errs), len(tt.want), err)
+		}
+	}
+}
+
+// TestConfigJSON checks that the JSON form of the Config of a scheme is
+// lossless: it rebuilds an Obfuscator which parses the ids of the original
+// and returns the same Config.
+func TestConfigJSON(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"default":     nil,
+		"64 bits":     {WithBits(64), WithByteOrder(binary.BigEndian)},
+		"feistel":     {WithFeistel(8, []byte("feistel key"))},
+		"keyed":       {WithMode(Keyed), WithKey([]byte("keyed key"))},
+		"sortable":    {WithMode(Sortable)},
+		"domain":      {WithDomainSalt("user")},
+		"encoding":    {WithEncoding(Base58), WithFixedWidth()},
+		"string form": {WithPrefix("u_"), WithMinLength(16), WithJSONNumber(), WithZeroString("")},
+		"compact":     {WithCompact(), WithTagBit()},
+		"nonce":       {WithNonce(8)},
+		"version":     {WithVersion(3, 2)},
+	} {
+		o, err := NewObfuscator(append([]Option{WithSeed(20200101)}, opts...)...)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		c := o.Config()
+		b, err := json.Marshal(c)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got, err := ParseConfig(b)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, c) {
+			t.Errorf("%s: %s parses to %+v, want %+v", name, b, got, c)
+		}
+		r, err := got.Obfuscator()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if rc := r.Config(); !reflect.DeepEqual(rc, c) {
+			t.Errorf("%s: the rebuilt scheme has the Config %+v, want %+v", name, rc, c)
+		}
+		for _, id := range []ID{0, 1, 100, ID(o.limit())} {
+			s := o.FormatID(id)
+			if got, err := r.StrictParseID(s); err != nil || got != id {
+				t.Errorf("%s: %q of %d parses to %d, %v by the rebuilt scheme", name, s, id, got, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package goobfuscated
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// WithSeed makes the scheme deterministic: the prime, its mod inverse and
+// the random mask are all derived from seed. Obfuscators created with the
+// same seed produce identical values on every run and on every machine.
+// The scheme is drawn from all of the 64 bits of seed, hashed with SHA-256, so
+// it is only as secret as the seed: prefer a random seed kept out of the
+// source, see WithSeedFromEnv, to a small or memorable one.
+func WithSeed(seed int64) Option {
+	return func(o *Obfuscator) error {
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], uint64(seed))
+		o.seed, o.seeded, o.seedBits = sha256.Sum256(buf[:]), true, 64
+		return nil
+	}
+}
+
+// WithSeedFromEnv is like WithSeed, but the seed is derived from the value of
+// the environment variable name, hashed with SHA-256 so that any string can be
+// used, e.g. WithSeedFromEnv("APP_ID_SEED"). The scheme then changes across
+// environments without code changes and the secret is kept out of the binary.
+// The 256 bits of the hash seed the scheme, so a long random value is worth
+// more than the 64 bits of WithSeed. It returns an error if the variable is
+// unset or empty.
+func WithSeedFromEnv(name string) Option {
+	return func(o *Obfuscator) error {
+		v := os.Getenv(name)
+		if v == "" {
+			return fmt.Errorf("environment variable %s is unset or empty", name)
+		}
+		o.seed, o.seeded, o.seedBits = sha256.Sum256([]byte(v)), true, 256
+		return nil
+	}
+}
+
+// WithPrime configures the prime used to obfuscate, instead of selecting one
+// from the local primes. It returns an error if prime is not a valid prime.
+func WithPrime(prime uint64) Option {
+	return func(o *Obfuscator) error {
+		if !isPrime(prime) {
+			return fmt.Errorf("%d is not a valid prime. [Accuracy: %f]", prime, accuracy())
+		}
+		o.prime = prime
+		return nil
+	}
+}
+
+// WithCustomPrimes replaces the local primes the prime is selected from, e.g.
+// with primes of the magnitude of the width of the scheme, see WithBits: the
+// local primes are about 4.5e8, a 32 bits scheme rather wants primes close to
+// 2^31.5. The prime is drawn from primes with the seed, see WithSeed. Every
+// entry must be a prime and coprime with the modulus 1<<bits, that is odd, see
+// ValidatePrime. It returns an error listing the invalid entries.
+func WithCustomPrimes(primes []uint64) Option {
+	return func(o *Obfuscator) error {
+		if len(primes) == 0 {
+			return errors.New("primes must not be empty")
+		}
+		var invalid []uint64
+		for _, p := range primes {
+			if p&1 == 0 || !isPrime(p) {
+				invalid = append(invalid, p)
+			}
+		}
+		if len(invalid) > 0 {
+			return fmt.Errorf("%v are not odd primes", invalid)
+		}
+		o.pool = append([]uint64(nil), primes...)
+		return nil
+	}
+}
+
+// WithRandomPrime makes NewObfuscator generate the prime from r instead of
+// selecting it from the local primes: a random point of the upper half of the
+// id space is read from r and the next prime is taken. The local table holds
+// about 240 primes, that is 8 bits of entropy, while a 53 bits scheme has
+// about 2^46 primes to pick from. r may be crypto/rand.Reader, or a seeded
+// math/rand source to reproduce the scheme. WithPrime takes precedence.
+func WithRandomPrime(r io.Reader) Option {
+	return func(o *Obfuscator) error {
+		if r == nil {
+			return errors.New("prime source must not be nil")
+		}
+		o.primeRand = r
+		return nil
+	}
+}
+
+// WithMask configures the random xor mask, it must be in the range [1,MaxInt],
+// or [1,1<<n - 1] when used with WithBits(n).
+func WithMask(mask uint64) Option {
+	return func(o *Obfuscator) error {
+		if mask == 0 {
+			return errors.New("mask must not be zero")
+		}
+		o.random = mask
+		return nil
+	}
+}
+
+// WithBits configures the width of the id space to n bits, the upper bound
+// of an id becomes 1<<n - 1 instead of MaxInt. n must be in the range
+// [MinBits,MaxBits], e.g. 32 for compact ids, 53 for JavaScript safe ids
+// (the default) or 64 for the full uint64 space.
+func WithBits(n int) Option {
+	return func(o *Obfuscator) error {
+		if n < MinBits || n > MaxBits {
+			return fmt.Errorf("bits %d is out of range [%d,%d]", n, MinBits, MaxBits)
+		}
+		o.bits, o.max = n, 1<<n-1
+		return nil
+	}
+}
+
+// WithByteOrder configures the byte order used to lay out the obfuscated value
+// before it is encoded by FormatID and decoded by ParseID. The default is
+// binary.LittleEndian, binary.BigEndian gives the network byte order.
+func WithByteOrder(order binary.ByteOrder) Option {
+	return func(o *Obfuscator) error {
+		if order == nil {
+			return errors.New("byte order must not be nil")
+		}
+		o.order = order
+		return nil
+	}
+}
+
+// WithJSONNumber makes ID marshal to JSON as the obfuscated value in a JSON
+// number instead of the obfuscated string. ID reads it from the default
+// Obfuscator, use NumericID to choose the number form per type.
+func WithJSONNumber() Option {
+	return func(o *Obfuscator) error {
+		o.jsonNumber = true
+		return nil
+	}
+}
+
+// WithMinLength makes FormatID pad the encoded id to at least n characters,
+// so that ids do not look conspicuously short. The padding is derived from the
+// obfuscated value, it is deterministic and is checked and stripped by ParseID.
+// Without it the encoded id is 11 characters long.
+func WithMinLength(n int) Option {
+	return func(o *Obfuscator) error {
+		if n < 0 || n > 256 {
+			return fmt.Errorf("min length %d is out of range [0,256]", n)
+		}
+		o.minLen = n
+		return nil
+	}
+}
+
+// WithFeistel obfuscates ids with a balanced Feistel network keyed by key
+// instead of Knuth's multiplicative hashing. The multiplicative hashing is
+// linear, a few pairs of ids and obfuscated values reveal the scheme, while
+// the network diffuses every bit of the id. It is still a bijection over the id
+// space, so DeObfuscate is exact. rounds must be in the range [3,64].
+func WithFeistel(rounds int, key []byte) Option {
+	return func(o *Obfuscator) error {
+		if rounds < 3 || rounds > 64 {
+			return fmt.Errorf("rounds %d is out of range [3,64]", rounds)
+		}
+		if len(key) == 0 {
+			return errors.New("feistel key must not be empty")
+		}
+		o.mode, o.rounds, o.key = Feistel, rounds, append([]byte(nil), key...)
+		return nil
+	}
+}
+
+// WithMode configures the algorithm used to obfuscate ids. Both the Feistel
+// and the Keyed mode require a key, see WithKey.
+func WithMode(mode Mode) Option {
+	return func(o *Obfuscator) error {
+		if mode < Multiplicative || mode > Sortable {
+			return fmt.Errorf("unknown mode %d", mode)
+		}
+		o.mode = mode
+		return nil
+	}
+}
+
+// WithKey configures the secret key of the Feistel and the Keyed mode.
+func WithKey(key []byte) Option {
+	return func(o *Obfuscator) error {
+		if len(key) == 0 {
+			return errors.New("key must not be empty")
+		}
+		o.key = append([]byte(nil), key...)
+		return nil
+	}
+}
+
+// WithEncoding configures the encoding of the string form of ids used by
+// FormatID and ParseID, e.g. Crockford, Base58, Hex or a custom Encoding.
+// The default is Base64.
+func WithEncoding(enc Encoding) Option {
+	return func(o *Obfuscator) error {
+		if enc == nil {
+			return errors.New("encoding must not be nil")
+		}
+		o.enc = enc
+		return nil
+	}
+}
+
+// WithPadding makes FormatID emit padded base64, the id is 12 characters long
+// instead of 11, for consumers which require the padding. ParseID accepts ids
+// with and without padding either way. It only applies to the default Base64
+// encoding, it is the same as WithEncoding(Base64Padded).
+func WithPadding(padded bool) Option {
+	return func(o *Obfuscator) error {
+		o.padded = padded
+		return nil
+	}
+}
+
+// WithZeroString makes FormatID return s for the zero id instead of its
+// obfuscated string, e.g. "" or "0" when zero is a sentinel such as
+// "unassigned" which must not look like the id of a record. ParseID returns
+// the zero id for s. s must not be the string of an id.
+func WithZeroString(s string) Option {
+	return func(o *Obfuscator) error {
+		o.zeroString, o.zeroSet = s, true
+		return nil
+	}
+}
+
+// WithFixedWidth makes FormatID emit ids of a single length with a variable
+// length encoding such as Base58: shorter ids are left-padded with the symbol
+// of the zero byte, "1" for Base58, so the length does not tell the magnitude
+// of the obfuscated value. ParseID strips the padding. The fixed length
+// encodings, e.g. Base64 or Crockford, are left as they are.
+func WithFixedWidth() Option {
+	return func(o *Obfuscator) error {
+		o.width = -1 // resolved by NewObfuscator.
+		return nil
+	}
+}
+
+// WithNonce reserves the upper n bits of the id space for a random nonce which
+// Obfuscate packs with the id, so that each call returns a different
+// obfuscated value and FormatID a different string for the same id, e.g. for
+// share links which can be told apart and revoked one by one. DeObfuscate and
+// ParseID strip the nonce and return the id. The ids are then limited to the
+// lower bits: with the default width of 53 bits and a nonce of 8 bits, the
+// upper bound of an id is 1<<45 - 1, see Capacity.
+func WithNonce(n int) Option {
+	return func(o *Obfuscator) error {
+		if n < 1 || n > 32 {
+			return fmt.Errorf("nonce bits %d is out of range [1,32]", n)
+		}
+		o.nonceBits = n
+		return nil
+	}
+}
+
+// WithCompact makes FormatID strip the leading zero bytes of the obfuscated
+// value, laid out in big-endian byte order, before encoding it, so that small
+// values render shorter. The length of the string tells the number of bytes,
+// ParseID restores the 8 bytes. The obfuscated values are spread over the
+// whole id space, the saving is mostly the unused upper bytes of the width:
+// with the default 53 bits, an id takes 7 bytes, 10 characters instead of 11,
+// and only 1 in 32 takes 6 bytes or less; with WithBits(32), an id takes 6
+// characters. It can not be combined with the Sortable mode, WithMinLength or
+// WithFixedWidth.
+func WithCompact() Option {
+	return func(o *Obfuscator) error {
+		o.compact = true
+		return nil
+	}
+}
+
+// WithParseCache makes ParseID memoize the ids of the last size strings it
+// parsed in an LRU cache, for the hot paths which parse the same strings over
+// and over, e.g. the id of the current user on every request. The cache is
+// safe for concurrent use, it is purged when o is rotated, see RotateTo, and
+// it is not inherited by Derive and WithNewMask. Only the successful parses
+// are cached. It returns an error if size is not positive.
+func WithParseCache(size int) Option {
+	return func(o *Obfuscator) error {
+		if size <= 0 {
+			return fmt.Errorf("parse cache size %d must be positive", size)
+		}
+		o.cache = newParseCache(size)
+		return nil
+	}
+}
+
+// WithTagBit reserves the upper bit of the id space to mark the obfuscated
+// values, against the bug of obfuscating a value twice: Obfuscate returns a
+// tagged value as it is, ObfuscateChecked returns an error, and the values
+// without the tag fail StrictParseID. The ids are permuted over the bits below
+// the tag, so the id space is halved, 1<<52 ids by default, and the
+// obfuscated values keep the width of the scheme. It can not be combined with
+// the Sortable mode, whose values fill the whole uint64.
+func WithTagBit() Option {
+	return func(o *Obfuscator) error {
+		o.tag = 1 // resolved by NewObfuscator.
+		return nil
+	}
+}
+
+// WithVersion reserves the upper n bits of the id space, below the tag bit if
+// any, for the version v of the scheme, so that the values tell which scheme
+// minted them: an Obfuscator can then be rotated to a scheme of another
+// version in any mode, see RotateTo. DeObfuscate and StrictParseID reject the
+// values of the other versions. The ids are permuted over the bits below the
+// version, with 4 bits the default space holds 1<<49 ids. v must fit in n
+// bits, n must be in the range [1,16] and it can not be combined with the
+// Sortable mode, whose values fill the whole uint64.
+func WithVersion(v uint64, n int) Option {
+	return func(o *Obfuscator) error {
+		if n < 1 || n > 16 {
+			return fmt.Errorf("version bits %d is out of range [1,16]", n)
+		}
+		o.version, o.versionBit = v, n
+		return nil
+	}
+}
+
+// WithPrefix makes FormatID emit prefix before the encoded id, e.g. "user_",
+// so that an id tells what it points to. ParseID returns an error if s does
+// not start with prefix. The prefix is not part of the obfuscated bytes, it
+// does not change the obfuscated value.
+func WithPrefix(prefix string) Option {
+	return func(o *Obfuscator) error {
+		o.prefix = prefix
+		return nil
+	}
+}
+
+// WithDomainSalt salts the scheme with the name of a domain, e.g. "user" or
+// "order", so that the same id obfuscates differently in each domain and
+// clients can not tell that user 5 and order 5 are the same number. The whole
+// scheme of the domain, its prime, its mask and the key of the Feistel and
+// Keyed mode, is derived with HKDF-SHA256 from the scheme configured by the
+// other options, its seed included, and from name, like Derive does: the
+// values of two domains do not tell how they relate. The prime of WithPrime
+// and the mask of WithMask are replaced by the derived ones.
+func WithDomainSalt(name string) Option {
+	return func(o *Obfuscator) error {
+		if name == "" {
+			return errors.New("domain name must not be empty")
+		}
+		o.domain = name
+		return nil
+	}
+}
@@ -0,0 +1,25 @@
+package goobfuscated
+
+// ObfuscatePair returns the obfuscated string of the pair of ids (hi, lo),
+// e.g. a tenant and an entity, so one string references both. The pair is
+// packed as hi<<32 | lo and formatted like FormatID. The packed value must be
+// in the range of o: a 64 bits scheme, see WithBits, holds any pair, the
+// default 53 bits one only the pairs whose hi is less than 1<<21. It returns
+// an error if the pair is out of range.
+func (o *Obfuscator) ObfuscatePair(hi, lo uint32) (string, error) {
+	if limit := o.limit(); uint64(hi) > limit>>32 {
+		return "", newError(ErrOutOfRange, "pair (%d, %d) is out of range, hi must be at most %d", hi, lo, limit>>32)
+	}
+	b, err := o.appendID(nil, uint64(hi)<<32|uint64(lo))
+	return string(b), err
+}
+
+// ParsePair is an inverse operation of ObfuscatePair, it parses s like
+// StrictParseID and unpacks the pair.
+func (o *Obfuscator) ParsePair(s string) (hi, lo uint32, err error) {
+	id, err := o.StrictParseID(s)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(id >> 32), uint32(id), nil
+}
@@ -0,0 +1,98 @@
+package goobfuscated
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// StringPattern returns a regular expression matching the strings returned by
+// FormatID, e.g. ^[A-Za-z0-9_-]{11}$ by default, to validate the ids of
+// request bodies in an OpenAPI or JSON schema. It reflects the encoding, the
+// prefix, the zero string and the length options of o. The length of a
+// variable length encoding is matched as a range, so the pattern may accept a
+// few strings which do not parse, use StrictParseID to validate an id.
+func (o *Obfuscator) StringPattern() string {
+	n, first := 8+o.padLen, 8+o.padLen
+	if o.compact {
+		first = 1
+	}
+	var body string
+	if o.enc == Phonetic {
+		body = `[a-z]+(?:-[a-z]+)` + quantifier(first-1, n-1)
+	} else {
+		lo, hi := math.MaxInt, 0
+		for k := first; k <= n; k++ {
+			zeros := len(o.enc.EncodeToString(make([]byte, k)))
+			ones := len(o.enc.EncodeToString(bytes.Repeat([]byte{0xff}, k)))
+			lo, hi = min(lo, zeros, ones), max(hi, zeros, ones)
+		}
+		if o.width > 0 {
+			lo = o.width
+		}
+		body = charClass(o.enc) + quantifier(lo, hi)
+	}
+	pattern := regexp.QuoteMeta(o.prefix) + body
+	if o.zeroSet {
+		pattern = "(?:" + pattern + "|" + regexp.QuoteMeta(o.zeroString) + ")"
+	}
+	return "^" + pattern + "$"
+}
+
+// quantifier returns the regular expression quantifier of lo to hi
+// repetitions.
+func quantifier(lo, hi int) string {
+	if lo == hi {
+		return fmt.Sprintf("{%d}", hi)
+	}
+	return fmt.Sprintf("{%d,%d}", lo, hi)
+}
+
+// charClass returns the character class of the alphabet of enc. The alphabet
+// of the encodings not provided by the package is collected from the strings
+// they encode.
+func charClass(enc Encoding) string {
+	switch enc {
+	case Base64, SortableBase64:
+		return `[A-Za-z0-9_-]`
+	case Base64Padded:
+		return `[A-Za-z0-9_=-]`
+	case Crockford:
+		return `[0-9A-HJKMNP-TV-Z]`
+	case Hex:
+		return `[0-9a-f]`
+	case Base45:
+		return `[0-9A-Z $%*+./:-]`
+	case Base58:
+		return `[1-9A-HJ-NP-Za-km-z]`
+	case Base62:
+		return `[0-9A-Za-z]`
+	}
+	src := make([]byte, 256)
+	for i := range src {
+		src[i] = byte(i)
+	}
+	s := enc.EncodeToString(src)
+	for i, j := 0, len(src)-1; i < j; i, j = i+1, j-1 {
+		src[i], src[j] = src[j], src[i]
+	}
+	s += enc.EncodeToString(src) + enc.EncodeToString(make([]byte, 8)) + enc.EncodeToString(bytes.Repeat([]byte{0xff}, 8))
+	chars := []byte(s)
+	sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, c := range chars {
+		switch {
+		case i > 0 && c == chars[i-1]:
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		}
+	}
+	b.WriteByte(']')
+	return b.String()
+}
@@ -0,0 +1,98 @@
+package goobfuscated
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Phonetic encodes every byte as a word, for ids read over the phone, e.g. by
+// support staff: an id is 8 words joined by hyphens, such as
+// "tracker-snowcap-cobra-...". The words are the 256 two-syllable words of the
+// PGP word list, chosen to be told apart when spoken, but every byte uses the
+// same list, so the strings are not PGP word list compatible. Decoding is
+// case-insensitive and accepts any mix of hyphens and white space between the
+// words.
+var Phonetic Encoding = phonetic{}
+
+// phoneticWords are the words of Phonetic, the word of byte b is
+// phoneticWords[b].
+var phoneticWords = [256]string{
+	"aardvark", "absurd", "accrue", "acme", "adrift", "adult", "afflict",
+	"ahead", "aimless", "algol", "allow", "alone", "ammo", "ancient", "apple",
+	"artist", "assume", "athens", "atlas", "aztec", "baboon", "backfield",
+	"backward", "banjo", "beaming", "bedlamp", "beehive", "beeswax", "befriend",
+	"belfast", "berserk", "billiard", "bison", "blackjack", "blockade",
+	"blowtorch", "bluebird", "bombast", "bookshelf", "brackish", "breadline",
+	"breakup", "brickyard", "briefcase", "burbank", "button", "buzzard",
+	"cement", "chairlift", "chatter", "checkup", "chisel", "choking", "chopper",
+	"christmas", "clamshell", "classic", "classroom", "cleanup", "clockwork",
+	"cobra", "commence", "concert", "cowbell", "crackdown", "cranky",
+	"crowfoot", "crucial", "crumpled", "crusade", "cubic", "dashboard",
+	"deadbolt", "deckhand", "dogsled", "dragnet", "drainage", "dreadful",
+	"drifter", "dropper", "drumbeat", "drunken", "dupont", "dwelling", "eating",
+	"edict", "egghead", "eightball", "endorse", "endow", "enlist", "erase",
+	"escape", "exceed", "eyeglass", "eyetooth", "facial", "fallout", "flagpole",
+	"flatfoot", "flytrap", "fracture", "framework", "freedom", "frighten",
+	"gazelle", "geiger", "glitter", "glucose", "goggles", "goldfish", "gremlin",
+	"guidance", "hamlet", "highchair", "hockey", "indoors", "indulge",
+	"inverse", "involve", "island", "jawbone", "keyboard", "kickoff", "kiwi",
+	"klaxon", "locale", "lockup", "merit", "minnow", "miser", "mohawk", "mural",
+	"music", "necklace", "neptune", "newborn", "nightbird", "oakland", "obtuse",
+	"offload", "optic", "orca", "payday", "peachy", "pheasant", "physique",
+	"playhouse", "pluto", "preclude", "prefer", "preshrunk", "printer",
+	"prowler", "pupil", "puppy", "python", "quadrant", "quiver", "quota",
+	"ragtime", "ratchet", "rebirth", "reform", "regain", "reindeer", "rematch",
+	"repay", "retouch", "revenge", "reward", "rhythm", "ribcage", "ringbolt",
+	"robust", "rocker", "ruffled", "sailboat", "sawdust", "scallion", "scenic",
+	"scorecard", "scotland", "seabird", "select", "sentence", "shadow",
+	"shamrock", "showgirl", "skullcap", "skydive", "slingshot", "slowdown",
+	"snapline", "snapshot", "snowcap", "snowslide", "solo", "southward",
+	"soybean", "spaniel", "spearhead", "spellbind", "spheroid", "spigot",
+	"spindle", "spyglass", "stagehand", "stagnate", "stairway", "standard",
+	"stapler", "steamship", "sterling", "stockman", "stopwatch", "stormy",
+	"sugar", "surmount", "suspense", "sweatband", "swelter", "tactics", "talon",
+	"tapeworm", "tempest", "tiger", "tissue", "tonic", "topmost", "tracker",
+	"transit", "trauma", "treadmill", "trojan", "trouble", "tumor", "tunnel",
+	"tycoon", "uncut", "unearth", "unwind", "uproot", "upset", "upshot",
+	"vapor", "village", "virus", "vulcan", "waffle", "wallet", "watchword",
+	"wayside", "willow", "woodlark", "zulu",
+}
+
+// phoneticIndex is the byte of each word of phoneticWords.
+var phoneticIndex = func() map[string]byte {
+	index := make(map[string]byte, len(phoneticWords))
+	for i, w := range phoneticWords {
+		index[w] = byte(i)
+	}
+	return index
+}()
+
+type phonetic struct{}
+
+func (e phonetic) EncodeToString(src []byte) string { return string(e.AppendEncode(nil, src)) }
+
+func (e phonetic) DecodeString(s string) ([]byte, error) { return e.AppendDecode(nil, []byte(s)) }
+
+func (phonetic) AppendEncode(dst, src []byte) []byte {
+	for i, b := range src {
+		if i > 0 {
+			dst = append(dst, '-')
+		}
+		dst = append(dst, phoneticWords[b]...)
+	}
+	return dst
+}
+
+func (phonetic) AppendDecode(dst, src []byte) ([]byte, error) {
+	words := strings.FieldsFunc(string(src), func(r rune) bool {
+		return r == '-' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	for _, w := range words {
+		b, ok := phoneticIndex[strings.ToLower(w)]
+		if !ok {
+			return dst, fmt.Errorf("unknown word %q", w)
+		}
+		dst = append(dst, b)
+	}
+	return dst, nil
+}
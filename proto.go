@@ -0,0 +1,122 @@
+package goobfuscated
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ProtoID is an ID which is serialized in protobuf messages as the bytes of
+// its obfuscated string, while it stays an integer in the generated structs.
+// It is meant for a bytes field:
+//
+//	bytes id = 1 [(gogoproto.customtype) = "github.com/19byte/goobfuscated.ProtoID", (gogoproto.nullable) = false];
+//
+// It targets two toolchains, which both call the methods of the custom type
+// from the generated code:
+//
+//   - gogo/protobuf, protoc-gen-gogo and its gogofast and gogofaster variants,
+//     with the customtype extension: Marshal, MarshalTo, Unmarshal, Size,
+//     MarshalJSON and UnmarshalJSON.
+//   - planetscale/vtprotobuf, protoc-gen-go-vtproto: MarshalVT, MarshalToVT,
+//     MarshalToSizedBufferVT, UnmarshalVT and SizeVT.
+//
+// The generated code of google.golang.org/protobuf, protoc-gen-go, has no
+// custom types, use a string field and grpcid there. The string is encoded by
+// the default Obfuscator, like ID.String.
+//
+// The generated code writes the length of the field from Size before calling
+// MarshalTo, so both must agree. A default Obfuscator WithNonce encodes each
+// call with a new nonce, which is only supported by the encodings of a fixed
+// length, e.g. the default base64 one or WithFixedWidth: with a variable
+// length one, e.g. Base58 or WithCompact, Marshal returns an error and Size
+// returns 0.
+type ProtoID ID
+
+// errProtoNonce is the error of a default Obfuscator whose ids ProtoID can
+// not size, see ProtoID.
+var errProtoNonce = errors.New("ProtoID requires ids of a fixed length with a nonce, see WithFixedWidth")
+
+// Marshal returns the bytes of the obfuscated string of id.
+func (id ProtoID) Marshal() ([]byte, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return nil, err
+	}
+	if o.primary().nonceBits != 0 && !o.fixedLength() {
+		return nil, errProtoNonce
+	}
+	return o.appendID(nil, uint64(id))
+}
+
+// MarshalTo writes the bytes of the obfuscated string of id to data, which
+// must hold at least Size bytes, and returns the number of bytes written.
+func (id *ProtoID) MarshalTo(data []byte) (int, error) {
+	b, err := id.Marshal()
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < len(b) {
+		return 0, fmt.Errorf("buffer of %d bytes is too short for %d bytes", len(data), len(b))
+	}
+	return copy(data, b), nil
+}
+
+// Unmarshal is an inverse operation of Marshal. Empty data resets the id to
+// zero.
+func (id *ProtoID) Unmarshal(data []byte) (err error) {
+	if len(data) == 0 {
+		*id = 0
+		return nil
+	}
+	var v ID
+	v, err = ParseID(string(data))
+	*id = ProtoID(v)
+	return err
+}
+
+// Size returns the number of bytes returned by Marshal, or 0 if it fails.
+func (id *ProtoID) Size() int {
+	if id == nil {
+		return 0
+	}
+	b, err := id.Marshal()
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
+// MarshalJSON satisfies json.Marshaller, it emits the id like ID.MarshalJSON.
+func (id ProtoID) MarshalJSON() ([]byte, error) { return ID(id).MarshalJSON() }
+
+// UnmarshalJSON satisfies json.Unmarshaler, see ID.UnmarshalJSON.
+func (id *ProtoID) UnmarshalJSON(b []byte) error { return (*ID)(id).UnmarshalJSON(b) }
+
+// MarshalVT is Marshal for vtprotobuf.
+func (id *ProtoID) MarshalVT() ([]byte, error) { return id.Marshal() }
+
+// MarshalToVT is MarshalTo for vtprotobuf.
+func (id *ProtoID) MarshalToVT(data []byte) (int, error) { return id.MarshalTo(data) }
+
+// MarshalToSizedBufferVT writes the bytes of the obfuscated string of id at
+// the end of data, vtprotobuf marshals messages backwards, and returns the
+// number of bytes written.
+func (id *ProtoID) MarshalToSizedBufferVT(data []byte) (int, error) {
+	b, err := id.Marshal()
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < len(b) {
+		return 0, fmt.Errorf("buffer of %d bytes is too short for %d bytes", len(data), len(b))
+	}
+	return copy(data[len(data)-len(b):], b), nil
+}
+
+// UnmarshalVT is Unmarshal for vtprotobuf.
+func (id *ProtoID) UnmarshalVT(data []byte) error { return id.Unmarshal(data) }
+
+// SizeVT is Size for vtprotobuf.
+func (id *ProtoID) SizeVT() int { return id.Size() }
+
+// ID returns id as an ID.
+func (id ProtoID) ID() ID { return ID(id) }
@@ -0,0 +1,78 @@
+package goobfuscated
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestProtoID(t *testing.T) {
+	o := setBenchDefault(t)
+	for _, id := range []ProtoID{1, 100, MaxInt} {
+		b, err := id.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := o.FormatID(ID(id)); string(b) != want {
+			t.Errorf("Marshal(%d) = %q, want %q", id, b, want)
+		}
+		if got := id.Size(); got != len(b) {
+			t.Errorf("Size(%d) = %d, want %d", id, got, len(b))
+		}
+		buf := make([]byte, id.Size()+2)
+		if n, err := id.MarshalTo(buf); err != nil || !bytes.Equal(buf[:n], b) {
+			t.Errorf("MarshalTo(%d) writes %q, %v, want %q", id, buf[:n], err, b)
+		}
+		if n, err := id.MarshalToSizedBufferVT(buf); err != nil || !bytes.Equal(buf[len(buf)-n:], b) {
+			t.Errorf("MarshalToSizedBufferVT(%d) writes %q, %v, want %q at the end", id, buf[len(buf)-n:], err, b)
+		}
+		if _, err := id.MarshalTo(buf[:len(b)-1]); err == nil {
+			t.Errorf("MarshalTo(%d) fits %d bytes into %d", id, len(b), len(b)-1)
+		}
+		var got ProtoID
+		if err := got.Unmarshal(b); err != nil || got != id {
+			t.Errorf("Unmarshal(%q) = %d, %v, want %d", b, got, err, id)
+		}
+	}
+	var zero ProtoID = 1
+	if err := zero.Unmarshal(nil); err != nil || zero != 0 {
+		t.Errorf("Unmarshal(nil) = %d, %v, want 0", zero, err)
+	}
+}
+
+// TestProtoIDNonce checks that Size agrees with Marshal for the default
+// Obfuscator WithNonce, whose every call encodes a new nonce, and that the
+// ids of a variable length are rejected.
+func TestProtoIDNonce(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		ok   bool
+	}{
+		{"base64", nil, true},
+		{"base58 fixed width", []Option{WithEncoding(Base58), WithFixedWidth()}, true},
+		{"base58", []Option{WithEncoding(Base58)}, false},
+		{"compact", []Option{WithCompact()}, false},
+	} {
+		resetDefault(t)
+		o, err := NewObfuscator(append([]Option{WithSeed(20200101), WithNonce(16)}, tt.opts...)...)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		SetDefault(o)
+		for id := ProtoID(1); id <= 100; id++ {
+			size := id.Size()
+			b, err := id.Marshal()
+			switch {
+			case !tt.ok:
+				if !errors.Is(err, errProtoNonce) || size != 0 {
+					t.Fatalf("%s: Marshal(%d) = %q, %v and Size %d, want errProtoNonce and 0", tt.name, id, b, err, size)
+				}
+			case err != nil:
+				t.Fatalf("%s: Marshal(%d): %v", tt.name, id, err)
+			case len(b) != size:
+				t.Fatalf("%s: Marshal(%d) = %q, %d bytes, but Size is %d", tt.name, id, b, len(b), size)
+			}
+		}
+	}
+}
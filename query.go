@@ -0,0 +1,75 @@
+package goobfuscated
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ObfuscateQuery returns a copy of v whose values of the keys are replaced by
+// the obfuscated strings of the decimal ids they hold, e.g. for ?user_id=5.
+// The other keys are copied untouched. A value which is not a decimal id in
+// range is left as it is and the returned error tells its key, the other
+// values are still replaced.
+func (o *Obfuscator) ObfuscateQuery(v url.Values, keys ...string) (url.Values, error) {
+	return translateQuery(v, keys, func(s string) (string, error) {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return "", newError(ErrInvalidFormat, "invalid id %q", s)
+		}
+		b, err := o.appendID(nil, id)
+		return string(b), err
+	})
+}
+
+// DeObfuscateQuery is an inverse operation of ObfuscateQuery, the values of
+// the keys are parsed like StrictParseID does and replaced by the decimal ids.
+func (o *Obfuscator) DeObfuscateQuery(v url.Values, keys ...string) (url.Values, error) {
+	return translateQuery(v, keys, func(s string) (string, error) {
+		id, err := o.StrictParseID(s)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatUint(id.Uint64(), 10), nil
+	})
+}
+
+// ObfuscateQuery is Obfuscator.ObfuscateQuery with the default Obfuscator.
+func ObfuscateQuery(v url.Values, keys ...string) (url.Values, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return nil, err
+	}
+	return o.ObfuscateQuery(v, keys...)
+}
+
+// DeObfuscateQuery is Obfuscator.DeObfuscateQuery with the default
+// Obfuscator.
+func DeObfuscateQuery(v url.Values, keys ...string) (url.Values, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return nil, err
+	}
+	return o.DeObfuscateQuery(v, keys...)
+}
+
+// translateQuery returns a copy of v with the values of keys replaced by f.
+func translateQuery(v url.Values, keys []string, f func(string) (string, error)) (url.Values, error) {
+	out := make(url.Values, len(v))
+	for k, vs := range v {
+		out[k] = append([]string(nil), vs...)
+	}
+	var errs []error
+	for _, k := range keys {
+		for i, s := range out[k] {
+			t, err := f(s)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("query key %q: %w", k, err))
+				continue
+			}
+			out[k][i] = t
+		}
+	}
+	return out, errors.Join(errs...)
+}
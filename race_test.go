@@ -0,0 +1,7 @@
+//go:build race
+
+package goobfuscated
+
+// sync.Pool drops items at random under the race detector, the pooled paths
+// then allocate.
+func init() { raceEnabled = true }
@@ -0,0 +1,162 @@
+package goobfuscated
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+)
+
+// keyring holds the schemes of a rotated Obfuscator. Each scheme is an
+// Obfuscator of its own which is only used through forward and backward.
+type keyring struct {
+	primary *Obfuscator   // scheme used to obfuscate.
+	retired []*Obfuscator // schemes still accepted to deobfuscate, newest first.
+}
+
+// RotateTo makes the scheme of next the primary scheme of o: Obfuscate always
+// uses it from then on, while DeObfuscate and ParseID fall back to the previous
+// schemes, newest first, until one of them produces an in-range value. This
+// lets links minted under the previous schemes keep working during a grace
+// window, which ends with ClearRetired. It is safe to call concurrently with
+// the other methods of o.
+//
+// Only the scheme (prime, mask, mode, key and version) is taken from next,
+// which must have the width of o, the string form keeps the options of o. The
+// fall back needs the primary scheme to reject the values it did not produce:
+// in the Multiplicative, Feistel and Keyed modes every value of the id space
+// is in range for every scheme, a value of a retired scheme would silently
+// decode to another id. The schemes of those modes are told apart by their
+// version, see WithVersion: o and next must reserve the same version bits and
+// next must hold a version which none of the schemes of o holds.
+//
+//	o, _ := NewObfuscator(WithSeed(seed1), WithVersion(1, 4))
+//	next, _ := NewObfuscator(WithSeed(seed2), WithVersion(2, 4))
+//	err := o.RotateTo(next)
+//
+// A scheme without version can only be rotated to the Sortable mode, whose
+// noise rejects the values of other schemes, but for a probability of about
+// 1/2^(63-bits).
+func (o *Obfuscator) RotateTo(next *Obfuscator) error {
+	if next.bits != o.bits {
+		return fmt.Errorf("can not rotate %d bits scheme to %d bits", o.bits, next.bits)
+	}
+	if (next.tag != 0) != (o.tag != 0) {
+		return errors.New("can not rotate between tagged and untagged schemes")
+	}
+	if next.versionBit != o.versionBit {
+		return fmt.Errorf("can not rotate a scheme of %d version bits to %d version bits", o.versionBit, next.versionBit)
+	}
+	p := next.primary()
+	if p.mode != Sortable && p.versionBit == 0 {
+		return fmt.Errorf("can not rotate to a %s scheme without version: its values do not tell the schemes apart, see WithVersion", modeNames[p.mode])
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	cur := o.keys.Load()
+	if cur == nil {
+		cur = &keyring{primary: o}
+	}
+	retired := append([]*Obfuscator{cur.primary}, cur.retired...)
+	for _, r := range retired {
+		if p.versionBit != 0 && r.version == p.version {
+			return fmt.Errorf("can not rotate to the version %d of the scheme again", p.version>>p.bits)
+		}
+	}
+	o.keys.Store(&keyring{primary: p, retired: retired})
+	if o.cache != nil {
+		o.cache.purge()
+	}
+	return nil
+}
+
+// ClearRetired drops the schemes o was rotated from, ids minted under them can
+// no longer be deobfuscated.
+func (o *Obfuscator) ClearRetired() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if cur := o.keys.Load(); cur != nil {
+		o.keys.Store(&keyring{primary: cur.primary})
+	}
+	if o.cache != nil {
+		o.cache.purge()
+	}
+}
+
+// primary returns the scheme used to obfuscate.
+func (o *Obfuscator) primary() *Obfuscator {
+	if k := o.keys.Load(); k != nil {
+		return k.primary
+	}
+	return o
+}
+
+// deObfuscate deobfuscates n with the primary scheme, falling back to the
+// retired ones. It reports whether a scheme produced an in-range value, that
+// is a value which obfuscates back to n, otherwise the value of the primary
+// scheme is returned. The nonce, if any, is stripped from the value.
+func (o *Obfuscator) deObfuscate(n uint64) (uint64, bool) {
+	k := o.keys.Load()
+	if k == nil {
+		id := o.backward(n)
+		return id & o.limit(), o.equal(o.forward(id), n)
+	}
+	id := k.primary.backward(n)
+	if k.primary.equal(k.primary.forward(id), n) {
+		return id & k.primary.limit(), true
+	}
+	for _, r := range k.retired {
+		if x := r.backward(n); r.equal(r.forward(x), n) {
+			return x & r.limit(), true
+		}
+	}
+	return id & k.primary.limit(), false
+}
+
+// equal reports whether a == b. The keyed modes compare in constant time, so
+// the check of StrictParseID does not leak where a forged value diverges, ids
+// handed out as capability tokens are usually obfuscated in those modes. The
+// Multiplicative and Sortable modes keep the plain comparison.
+func (o *Obfuscator) equal(a, b uint64) bool {
+	if !o.keyed() {
+		return a == b
+	}
+	x := a ^ b
+	return subtle.ConstantTimeEq(int32(uint32(x>>32)|uint32(x)), 0) == 1
+}
+
+// keyed reports whether o obfuscates in one of the keyed modes.
+func (o *Obfuscator) keyed() bool { return o.mode == Feistel || o.mode == Keyed }
+
+// Reencode returns the string of to for the id of the string s of from, to
+// migrate stored strings from one scheme to another, e.g. after a change of
+// the prime or the mask without RotateTo. s is parsed like StrictParseID
+// does, so a string which was never valid under from returns an error.
+func Reencode(s string, from, to *Obfuscator) (string, error) {
+	id, err := from.StrictParseID(s)
+	if err != nil {
+		return "", err
+	}
+	b, err := to.appendID(nil, id.Uint64())
+	return string(b), err
+}
+
+// ReencodeSlice is Reencode for each string of ss. All the strings are
+// reencoded, those which fail are left empty and the returned error is a
+// ParseErrors holding the error of each of them.
+func ReencodeSlice(ss []string, from, to *Obfuscator) ([]string, error) {
+	out := make([]string, len(ss))
+	var errs ParseErrors
+	for i, s := range ss {
+		var err error
+		if out[i], err = Reencode(s, from, to); err != nil {
+			if errs == nil {
+				errs = ParseErrors{}
+			}
+			errs[i] = err
+		}
+	}
+	if errs != nil {
+		return out, errs
+	}
+	return out, nil
+}
@@ -0,0 +1,99 @@
+package goobfuscated
+
+import (
+	"errors"
+	"testing"
+)
+
+func newVersioned(t *testing.T, seed int64, version uint64, opts ...Option) *Obfuscator {
+	t.Helper()
+	o, err := NewObfuscator(append([]Option{WithSeed(seed), WithVersion(version, 4)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestRotateTo(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"multiplicative": nil,
+		"feistel":        {WithFeistel(8, []byte("feistel key"))},
+		"keyed":          {WithMode(Keyed), WithKey([]byte("keyed key"))},
+		"tagged":         {WithTagBit()},
+	} {
+		o := newVersioned(t, 1, 1, opts...)
+		ids := []ID{0, 1, 100, ID(o.limit())}
+		old := make([]string, len(ids))
+		for i, id := range ids {
+			old[i] = o.FormatID(id)
+		}
+		next := newVersioned(t, 2, 2, opts...)
+		if err := o.RotateTo(next); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for i, id := range ids {
+			if got, err := o.StrictParseID(old[i]); err != nil || got != id {
+				t.Errorf("%s: %q of the retired scheme parses to %d, %v, want %d", name, old[i], got, err, id)
+			}
+			s := o.FormatID(id)
+			if s != next.FormatID(id) {
+				t.Errorf("%s: %d formats to %q, not to %q of the primary scheme", name, id, s, next.FormatID(id))
+			}
+			if got, err := o.StrictParseID(s); err != nil || got != id {
+				t.Errorf("%s: %q parses to %d, %v, want %d", name, s, got, err, id)
+			}
+		}
+		o.ClearRetired()
+		for i := range ids {
+			if got, err := o.StrictParseID(old[i]); err == nil {
+				t.Errorf("%s: %q of a cleared scheme parses to %d", name, old[i], got)
+			}
+		}
+	}
+}
+
+func TestRotateToErrors(t *testing.T) {
+	o := newVersioned(t, 1, 1)
+	unversioned, err := NewObfuscator(WithSeed(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, next := range map[string]*Obfuscator{
+		"unversioned":        unversioned,
+		"same version":       newVersioned(t, 2, 1),
+		"other version bits": newVersioned(t, 2, 2, WithVersion(2, 8)),
+	} {
+		if err := o.RotateTo(next); err == nil {
+			t.Errorf("%s: RotateTo succeeds", name)
+		}
+	}
+	if err := unversioned.RotateTo(newVersioned(t, 3, 2)); err == nil {
+		t.Error("an unversioned scheme rotates to a versioned one")
+	}
+	if err := o.RotateTo(newVersioned(t, 2, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.RotateTo(newVersioned(t, 3, 1)); err == nil {
+		t.Error("RotateTo accepts the version of a retired scheme")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	v1, v2 := newVersioned(t, 1, 1), newVersioned(t, 1, 2)
+	if got, want := v1.Capacity(), uint64(1)<<49; got != want {
+		t.Errorf("Capacity() = %d, want %d", got, want)
+	}
+	if _, err := v2.StrictParseID(v1.FormatID(100)); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("a value of the version 1 parses with the version 2: %v", err)
+	}
+	for i, opts := range [][]Option{
+		{WithVersion(16, 4)},
+		{WithVersion(0, 0)},
+		{WithVersion(0, 4), WithMode(Sortable)},
+		{WithBits(10), WithVersion(0, 4)},
+	} {
+		if _, err := NewObfuscator(opts...); err == nil {
+			t.Errorf("NewObfuscator accepts the options %d", i)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package goobfuscated
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SecurityReport summarizes how guessable the values of a scheme are, see
+// Obfuscator.SecurityReport. The figures are upper bounds of the effort of an
+// attacker who knows the package and its defaults but not the scheme.
+type SecurityReport struct {
+	Mode   Mode
+	Linear bool // a few pairs of ids and values are enough to recover the scheme.
+
+	// PrimeCandidates is the number of primes the prime is drawn from, the
+	// local primes or the ones of WithCustomPrimes, or an estimate of the
+	// primes of the upper half of the id space with WithRandomPrime. A prime
+	// configured WithPrime is counted as drawn from the pool as well.
+	PrimeCandidates uint64
+	PrimeBits       float64 // log2 of PrimeCandidates.
+	MaskBits        int     // width of the range the mask is drawn from.
+	KeyBits         int     // length of the key of the Feistel and Keyed modes.
+
+	// SeedBits caps the bits above: a seeded scheme is fully derived from its
+	// seed, 64 bits with WithSeed and the 256 bits of the hash of the variable
+	// with WithSeedFromEnv, which is only as strong as its value. It is 0 for
+	// an unseeded scheme.
+	SeedBits int
+
+	Caveat string // what the mode does not protect against, in plain English.
+}
+
+// modeNames names the modes in a SecurityReport.
+var modeNames = map[Mode]string{
+	Multiplicative: "multiplicative",
+	Feistel:        "feistel",
+	Keyed:          "keyed",
+	Sortable:       "sortable",
+}
+
+// SecurityReport returns the report of the primary scheme of o, e.g. to write
+// down in a security review how guessable the ids are. The important part is
+// the caveat: the default Multiplicative mode is NOT encryption.
+func (o *Obfuscator) SecurityReport() SecurityReport {
+	p := o.primary()
+	r := SecurityReport{
+		Mode:            p.mode,
+		Linear:          p.mode == Multiplicative,
+		PrimeCandidates: uint64(len(p.pool)),
+		MaskBits:        p.bits,
+	}
+	if p.primeRand != nil {
+		// The prime number theorem gives about 2^(b-1) / (b ln 2) primes in
+		// the upper half of a b bits space.
+		b := float64(p.bits)
+		r.PrimeCandidates = uint64(math.Exp2(b-1) / (b * math.Ln2))
+	}
+	if r.PrimeCandidates > 0 {
+		r.PrimeBits = math.Log2(float64(r.PrimeCandidates))
+	}
+	if p.seeded {
+		r.SeedBits = p.seedBits
+	}
+	switch p.mode {
+	case Multiplicative:
+		r.Caveat = "Multiplicative obfuscation is NOT encryption. It is linear: " +
+			"two or three pairs of ids and obfuscated values, e.g. the ids of a " +
+			"user's own records, are enough to recover the prime and the mask and " +
+			"then to decode every id. It hides the sequence of ids from casual " +
+			"observers, do not rely on it to keep ids secret or unguessable."
+	case Feistel:
+		r.KeyBits = 8 * len(p.key)
+		r.Caveat = "The Feistel mode is NOT encryption. It diffuses every bit of " +
+			"the id, but its round function is not a cryptographic one and it was " +
+			"not analysed against a determined attacker. Use the Keyed mode if the " +
+			"ids must stay secret."
+	case Keyed:
+		r.KeyBits = 8 * len(p.key)
+		r.Caveat = fmt.Sprintf("The Keyed mode is a format preserving encryption "+
+			"in the fashion of FF1, it is as strong as its key. The values remain "+
+			"%d bits wide: a random value is the value of an existing id as often "+
+			"as ids fill the space, do not use ids as access tokens.", p.bits)
+	case Sortable:
+		r.Linear = true
+		r.Caveat = "The Sortable mode is NOT encryption. It leaks the order of ids " +
+			"by design and the value is the id times a secret stride plus noise: " +
+			"the ratio of two values is about the ratio of their ids, and a single " +
+			"pair of an id and its value reveals the stride and thus every id."
+	}
+	return r
+}
+
+// String returns the report as a few lines of plain English.
+func (r SecurityReport) String() string {
+	var b strings.Builder
+	kind := "keyed"
+	if r.Linear {
+		kind = "linear"
+	}
+	if modeNames[r.Mode] == kind {
+		fmt.Fprintf(&b, "mode: %s\n", kind)
+	} else {
+		fmt.Fprintf(&b, "mode: %s (%s)\n", modeNames[r.Mode], kind)
+	}
+	switch r.Mode {
+	case Multiplicative:
+		fmt.Fprintf(&b, "prime: 1 of %d candidates (%.1f bits)\n", r.PrimeCandidates, r.PrimeBits)
+		fmt.Fprintf(&b, "mask: %d bits\n", r.MaskBits)
+	case Sortable:
+		fmt.Fprintf(&b, "stride and noise key: %d bits\n", r.MaskBits)
+	default:
+		fmt.Fprintf(&b, "key: %d bits\n", r.KeyBits)
+	}
+	if r.SeedBits > 0 {
+		fmt.Fprintf(&b, "seed: %d bits, the scheme is derived from it\n", r.SeedBits)
+	}
+	b.WriteString(r.Caveat)
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package goobfuscated
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// ObfuscatorSet routes each id to one of several Obfuscators, a keyed set of
+// permutations, so that the ids are not all obfuscated with a single prime.
+// The id is routed to the Obfuscator of index id % N, and the index is stored
+// in the bits above the obfuscated value, so the set knows which inverse to
+// apply. The values of a set of N Obfuscators of WithBits(n) are
+// n + bits.Len(N-1) bits wide, e.g. 4 Obfuscators of WithBits(51) give values
+// below MaxInt.
+//
+// The index is the id modulo N, so it is xored with a hash of the obfuscated
+// value keyed by the schemes of the set before it is stored: a clear index
+// would tell the parity of every id of a set of 2. The hash is not a
+// cryptographic one, the set is not stronger than its members, see
+// SecurityReport.
+type ObfuscatorSet struct {
+	obs   []*Obfuscator
+	bits  int    // width of the obfuscated values of the members.
+	sel   uint64 // mask of the index bits.
+	key   uint64 // key of the hash masking the index.
+	limit uint64
+}
+
+// NewObfuscatorSet returns a set of obs, which must share the same width, see
+// WithBits, and use distinct schemes, see Obfuscator.Derive. The string form of
+// the set, see FormatID, is the one of the first Obfuscator. It returns an
+// error if obs is empty or if the values of the set overflow 64 bits.
+func NewObfuscatorSet(obs ...*Obfuscator) (*ObfuscatorSet, error) {
+	if len(obs) == 0 {
+		return nil, errors.New("obfuscator set must not be empty")
+	}
+	w := obs[0].primary().valueBits()
+	for _, o := range obs[1:] {
+		if b := o.primary().valueBits(); b != w {
+			return nil, fmt.Errorf("can not mix %d bits and %d bits schemes in a set", w, b)
+		}
+	}
+	sel := bits.Len(uint(len(obs) - 1))
+	if w+sel > 64 {
+		return nil, fmt.Errorf("%d bits schemes and %d selector bits overflow 64 bits", w, sel)
+	}
+	h := sha256.New()
+	for _, o := range obs {
+		h.Write(o.primary().secret())
+	}
+	key := littleEndian.Uint64(h.Sum(nil))
+	return &ObfuscatorSet{obs: obs, bits: w, sel: 1<<sel - 1, key: key, limit: obs[0].limit()}, nil
+}
+
+// Obfuscate returns the obfuscated value of id by the Obfuscator of index
+// id % N, with the masked index in its upper bits. An id out of the range of
+// the Obfuscators is wrapped like Obfuscator.Obfuscate does.
+func (set *ObfuscatorSet) Obfuscate(id uint64) uint64 {
+	id &= set.limit
+	i := id % uint64(len(set.obs))
+	v := set.obs[i].Obfuscate(id)
+	return (i^round(v, set.key)&set.sel)<<set.bits | v
+}
+
+// DeObfuscate is an inverse operation of Obfuscate, the index unmasked from
+// the upper bits of n selects the Obfuscator which decodes it.
+func (set *ObfuscatorSet) DeObfuscate(n uint64) uint64 {
+	id, _ := set.deObfuscate(n)
+	return id
+}
+
+// deObfuscate returns the id of n, and whether n is the obfuscated value of
+// an id: its index is in range and matches the id.
+func (set *ObfuscatorSet) deObfuscate(n uint64) (uint64, bool) {
+	v := n & (1<<set.bits - 1)
+	sel := n >> set.bits
+	if sel > set.sel {
+		return 0, false
+	}
+	i := sel ^ round(v, set.key)&set.sel
+	if i >= uint64(len(set.obs)) {
+		return 0, false
+	}
+	id, ok := set.obs[i].deObfuscate(v)
+	return id, ok && id%uint64(len(set.obs)) == i
+}
+
+// FormatID returns the string form of the obfuscated value of id, like
+// Obfuscator.FormatID of the first Obfuscator of the set.
+func (set *ObfuscatorSet) FormatID(id ID) string {
+	return set.obs[0].format(set.Obfuscate(id.Uint64()))
+}
+
+// ParseID is an inverse operation of FormatID. It returns an error if s does
+// not hold the obfuscated value of an id of the set, see StrictParseID.
+func (set *ObfuscatorSet) ParseID(s string) (ID, error) {
+	n, err := set.obs[0].decodeValue(s)
+	if err != nil {
+		return 0, err
+	}
+	id, ok := set.deObfuscate(n)
+	if !ok {
+		return 0, ErrOutOfRange
+	}
+	return ID(id), nil
+}
@@ -0,0 +1,43 @@
+package goobfuscated
+
+// ObfuscateSigned is like ObfuscateChecked for a signed id, e.g. of a table
+// using negative ids for special rows. The id is zigzag encoded before it is
+// obfuscated, 0, -1, 1, -2, 2... map to 0, 1, 2, 3, 4..., so that a 53 bits
+// scheme holds the ids in the range [-(1<<52), 1<<52-1]. It returns an error
+// if the zigzag encoded id is out of range instead of wrapping it.
+func (o *Obfuscator) ObfuscateSigned(id int64) (uint64, error) {
+	z := uint64(id<<1) ^ uint64(id>>63)
+	if z > o.limit() {
+		return 0, newError(ErrOutOfRange, "signed id %d is out of range", id)
+	}
+	return o.Obfuscate(z), nil
+}
+
+// DeObfuscateSigned is an inverse operation of ObfuscateSigned, it returns an
+// error if n is not the obfuscated value of an id, see StrictParseID.
+func (o *Obfuscator) DeObfuscateSigned(n uint64) (int64, error) {
+	z, ok := o.deObfuscate(n)
+	if !ok {
+		return 0, ErrOutOfRange
+	}
+	return int64(z>>1) ^ -int64(z&1), nil
+}
+
+// ObfuscateSigned is like Obfuscator.ObfuscateSigned with the default
+// Obfuscator.
+func ObfuscateSigned(id int64) (uint64, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	return o.ObfuscateSigned(id)
+}
+
+// DeObfuscateSigned is an inverse operation of ObfuscateSigned.
+func DeObfuscateSigned(n uint64) (int64, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	return o.DeObfuscateSigned(n)
+}
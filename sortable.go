@@ -0,0 +1,40 @@
+package goobfuscated
+
+import "encoding/base64"
+
+// SortableBase64 is a base64 encoding whose alphabet is in ASCII order, so
+// that the lexical order of the encoded strings matches the order of the
+// big-endian bytes. It is the default encoding of the Sortable mode.
+var SortableBase64 Encoding = base64.NewEncoding("-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz").WithPadding(base64.NoPadding)
+
+// sortableMaxBits is the widest id space of the Sortable mode, it leaves at
+// least 8 bits of noise.
+const sortableMaxBits = 56
+
+// sortable is the order preserving transform of the Sortable mode. An id is
+// multiplied by a secret stride and keyed noise derived from the id, below the
+// stride, is added, so that a < b implies forward(a) < forward(b). The
+// transform is reversible by dividing by the stride. The stride is drawn from
+// the key in [1<<(63-bits), 1<<(64-bits)), so the values fit in a uint64.
+type sortable struct {
+	stride uint64 // multiplier of the id, the noise is below it.
+	key    uint64 // key of the noise.
+	max    uint64
+}
+
+// newSortable returns the Sortable transform of a bits wide id space keyed by
+// key.
+func newSortable(bits int, key uint64) *sortable {
+	half := uint64(1) << (63 - bits)
+	return &sortable{stride: half | round(key, strideKey)&(half-1), key: key, max: 1<<bits - 1}
+}
+
+// strideKey separates the stride from the noise drawn from the same key.
+const strideKey = 0x5f3759df5f3759df
+
+func (s *sortable) forward(x uint64) uint64 {
+	x &= s.max
+	return x*s.stride + round(x, s.key)%s.stride
+}
+
+func (s *sortable) backward(x uint64) uint64 { return x / s.stride }
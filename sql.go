@@ -0,0 +1,149 @@
+package goobfuscated
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Scan satisfies sql.Scanner, it reads the raw value of the id from a BIGINT
+// column. The obfuscation only happens at the JSON/string boundary, the
+// database always holds the raw value. Scanning NULL resets the id to zero.
+func (id *ID) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*id = 0
+	case int64:
+		if v < 0 {
+			return newError(ErrOutOfRange, "can not scan negative value %d into id", v)
+		}
+		*id = ID(v)
+	case []byte:
+		return id.scanString(string(v))
+	case string:
+		return id.scanString(v)
+	default:
+		return fmt.Errorf("can not scan %T into id", src)
+	}
+	return nil
+}
+
+// scanString parses the raw decimal value of the id.
+func (id *ID) scanString(s string) error {
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return newError(ErrInvalidFormat, "fails to scan id: %w", err)
+	}
+	*id = ID(n)
+	return nil
+}
+
+// Value satisfies driver.Valuer, it returns the raw value of the id as an
+// int64 so it can be stored in a BIGINT column. It replaces the Value method
+// of earlier versions, which returned the raw uint64, use Uint64 instead.
+func (id ID) Value() (driver.Value, error) {
+	if id > math.MaxInt64 {
+		return nil, newError(ErrOutOfRange, "id %d overflows int64", uint64(id))
+	}
+	return int64(id), nil
+}
+
+// GormDataType satisfies schema.GormDataTypeInterface of GORM, so columns of
+// type ID are inferred as BIGINT. Like Value, the column holds the raw value of
+// the id, not the obfuscated string, and the zero id is stored as 0.
+func (ID) GormDataType() string { return "bigint" }
+
+// TextID is an ID stored in the database as its obfuscated string, in a TEXT
+// column, for the tables which hold the string clients see instead of the raw
+// value. ID stores the raw value in a BIGINT column, TextID the string of
+// ID.String, both are the raw value in Go.
+type TextID ID
+
+// Scan satisfies sql.Scanner, it parses the obfuscated string of a TEXT column
+// like ParseID does. Scanning NULL resets the id to zero.
+func (id *TextID) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*id = 0
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("can not scan %T into text id", src)
+	}
+	v, err := ParseID(s)
+	*id = TextID(v)
+	return err
+}
+
+// Value satisfies driver.Valuer, it returns the obfuscated string of the id.
+func (id TextID) Value() (driver.Value, error) {
+	text, err := ID(id).MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// GormDataType satisfies schema.GormDataTypeInterface of GORM, so columns of
+// type TextID are inferred as TEXT.
+func (TextID) GormDataType() string { return "text" }
+
+// ID returns id as an ID.
+func (id TextID) ID() ID { return ID(id) }
+
+// NullID is an ID which may be NULL, like sql.NullInt64. It is stored as the
+// raw value like ID, an invalid NullID is NULL in the database and null in
+// JSON.
+type NullID struct {
+	ID    ID
+	Valid bool // Valid is true if ID is not NULL.
+}
+
+// Scan satisfies sql.Scanner, scanning NULL makes the id invalid, see ID.Scan
+// for the other values.
+func (n *NullID) Scan(src any) error {
+	if src == nil {
+		n.ID, n.Valid = 0, false
+		return nil
+	}
+	n.Valid = true
+	return n.ID.Scan(src)
+}
+
+// Value satisfies driver.Valuer, it returns nil for an invalid id, see
+// ID.Value.
+func (n NullID) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.ID.Value()
+}
+
+// MarshalJSON satisfies json.Marshaller, it emits null for an invalid id, see
+// ID.MarshalJSON.
+func (n NullID) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return n.ID.MarshalJSON()
+}
+
+// UnmarshalJSON satisfies json.Unmarshaler, null makes the id invalid, see
+// ID.UnmarshalJSON.
+func (n *NullID) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		n.ID, n.Valid = 0, false
+		return nil
+	}
+	n.Valid = true
+	return n.ID.UnmarshalJSON(b)
+}
+
+// GormDataType satisfies schema.GormDataTypeInterface of GORM, so columns of
+// type NullID are inferred as BIGINT.
+func (NullID) GormDataType() string { return "bigint" }
@@ -0,0 +1,98 @@
+package goobfuscated
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LineError is the error of a malformed line of EncodeStream or DecodeStream.
+type LineError struct {
+	Line int   // line number, starting at 1.
+	Err  error // error of the line.
+}
+
+func (e *LineError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+
+func (e *LineError) Unwrap() error { return e.Err }
+
+// StreamOption configures EncodeStream and DecodeStream.
+type StreamOption func(*streamConfig)
+
+type streamConfig struct {
+	continueOnError bool
+}
+
+// ContinueOnError makes EncodeStream and DecodeStream carry on after a
+// malformed line instead of stopping at the first one. The malformed lines are
+// written as empty lines, so the output stays aligned with the input, and
+// their errors are returned joined once the stream is done.
+func ContinueOnError() StreamOption {
+	return func(c *streamConfig) { c.continueOnError = true }
+}
+
+// EncodeStream reads newline delimited decimal ids from in and writes their
+// obfuscated strings to out, one per line. Empty lines are kept as they are.
+// A malformed line stops the stream with a *LineError, see ContinueOnError.
+func EncodeStream(in io.Reader, out io.Writer, o *Obfuscator, opts ...StreamOption) error {
+	return convertStream(in, out, opts, func(w *bufio.Writer, line string) error {
+		id, err := strconv.ParseUint(line, 10, 64)
+		if err != nil {
+			return newError(ErrInvalidFormat, "invalid id %q", line)
+		}
+		b, err := o.appendID(w.AvailableBuffer(), id)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	})
+}
+
+// DecodeStream is an inverse operation of EncodeStream, it reads newline
+// delimited obfuscated strings and writes the decimal ids. The strings are
+// parsed like StrictParseID does.
+func DecodeStream(in io.Reader, out io.Writer, o *Obfuscator, opts ...StreamOption) error {
+	return convertStream(in, out, opts, func(w *bufio.Writer, line string) error {
+		id, err := o.StrictParseID(line)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(strconv.AppendUint(w.AvailableBuffer(), id.Uint64(), 10))
+		return err
+	})
+}
+
+// convertStream writes each line of in converted by f to out.
+func convertStream(in io.Reader, out io.Writer, opts []StreamOption, f func(*bufio.Writer, string) error) error {
+	var c streamConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	var errs []error
+	r, w := bufio.NewScanner(in), bufio.NewWriter(out)
+	for i := 1; r.Scan(); i++ {
+		if line := strings.TrimSpace(r.Text()); line != "" {
+			if err := f(w, line); err != nil {
+				if !c.continueOnError {
+					w.Flush()
+					return &LineError{Line: i, Err: err}
+				}
+				errs = append(errs, &LineError{Line: i, Err: err})
+			}
+		}
+		if err := w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,191 @@
+package goobfuscated
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// ObfuscateStruct replaces in place the raw ids of the fields of the struct
+// pointed to by v which are tagged `obfuscate:"id"` by their obfuscated
+// values, e.g. to convert the DTOs of an API without touching their
+// serializers. A tagged field is a uint64 or an int64, of any named type, a
+// pointer to one, or a slice or an array of them. The tag option omitzero,
+// `obfuscate:"id,omitzero"`, leaves the zero ids as they are. The nested
+// structs are walked, through pointers, slices, arrays and interfaces holding
+// pointers, the unexported fields and the maps are not. A struct pointed to
+// twice is converted once.
+//
+// It returns an error if an id is out of range, see ObfuscateChecked, or if
+// an obfuscated value overflows an int64 field, the fields before the failing
+// one are converted already. The walk goes through reflection, it takes of
+// the order of a microsecond per struct, where converting the fields by hand
+// with Obfuscate takes a few nanoseconds per id.
+func (o *Obfuscator) ObfuscateStruct(v any) error {
+	return walkStruct(v, o.ObfuscateChecked)
+}
+
+// DeObfuscateStruct is an inverse operation of ObfuscateStruct, it returns an
+// error if a value is not the obfuscated value of an id, see StrictParseID.
+func (o *Obfuscator) DeObfuscateStruct(v any) error {
+	return walkStruct(v, func(n uint64) (uint64, error) {
+		id, ok := o.deObfuscate(n)
+		if !ok {
+			return 0, ErrOutOfRange
+		}
+		return id, nil
+	})
+}
+
+// ObfuscateStruct is like Obfuscator.ObfuscateStruct with the default
+// Obfuscator.
+func ObfuscateStruct(v any) error {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return err
+	}
+	return o.ObfuscateStruct(v)
+}
+
+// DeObfuscateStruct is an inverse operation of ObfuscateStruct.
+func DeObfuscateStruct(v any) error {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return err
+	}
+	return o.DeObfuscateStruct(v)
+}
+
+// structWalker converts the tagged fields of a struct with f.
+type structWalker struct {
+	f    func(uint64) (uint64, error)
+	seen map[pointer]bool // pointers walked already, in case of cycles.
+}
+
+// pointer identifies a pointer walked by structWalker, a struct and its first
+// field share their address.
+type pointer struct {
+	t reflect.Type
+	p uintptr
+}
+
+// walkStruct converts with f the tagged fields of the struct pointed to by v.
+func walkStruct(v any, f func(uint64) (uint64, error)) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("can not walk %T, a non-nil pointer to a struct is required", v)
+	}
+	w := &structWalker{f: f, seen: make(map[pointer]bool)}
+	return w.walk(rv, "")
+}
+
+// walk converts the tagged fields of the structs held by v.
+func (w *structWalker) walk(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		key := pointer{v.Type(), v.Pointer()}
+		if v.IsNil() || w.seen[key] {
+			return nil
+		}
+		w.seen[key] = true
+		return w.walk(v.Elem(), path)
+	case reflect.Interface:
+		if e := v.Elem(); e.Kind() == reflect.Pointer {
+			return w.walk(e, path)
+		}
+	case reflect.Slice, reflect.Array:
+		if !mayHoldStruct(v.Type().Elem()) {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := w.walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() && !sf.Anonymous {
+				continue
+			}
+			name := sf.Name
+			if path != "" {
+				name = path + "." + sf.Name
+			}
+			tag, ok := sf.Tag.Lookup("obfuscate")
+			if !ok {
+				if err := w.walk(v.Field(i), name); err != nil {
+					return err
+				}
+				continue
+			}
+			kind, opts, _ := strings.Cut(tag, ",")
+			if kind != "id" {
+				return fmt.Errorf("field %s: unknown obfuscate tag %q", name, tag)
+			}
+			if err := w.convert(v.Field(i), opts == "omitzero"); err != nil {
+				return fmt.Errorf("field %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// convert converts the ids held by the tagged field v.
+func (w *structWalker) convert(v reflect.Value, omitZero bool) error {
+	if !v.CanSet() {
+		return errors.New("field can not be set")
+	}
+	switch v.Kind() {
+	case reflect.Uint64:
+		if omitZero && v.Uint() == 0 {
+			return nil
+		}
+		n, err := w.f(v.Uint())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Int64:
+		if omitZero && v.Int() == 0 {
+			return nil
+		}
+		if v.Int() < 0 {
+			return newError(ErrOutOfRange, "id %d is negative", v.Int())
+		}
+		n, err := w.f(uint64(v.Int()))
+		if err != nil {
+			return err
+		}
+		if n > math.MaxInt64 {
+			return newError(ErrOutOfRange, "value %d overflows int64", n)
+		}
+		v.SetInt(int64(n))
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return w.convert(v.Elem(), omitZero)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := w.convert(v.Index(i), omitZero); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+	default:
+		return fmt.Errorf("type %s can not hold an id", v.Type())
+	}
+	return nil
+}
+
+// mayHoldStruct reports whether the values of t may hold a struct to walk.
+func mayHoldStruct(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
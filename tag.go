@@ -0,0 +1,24 @@
+package goobfuscated
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// ShortTag returns a 6 characters tag of id, e.g. to group the log lines of
+// an entity. Unlike FormatID, the tag is one-way: it is a truncated
+// HMAC-SHA256 of the raw id keyed by the secret of the scheme of o, so it can
+// not be parsed back, and without the scheme it can not be matched to an id by
+// trying every id either. It holds 30 bits, distinct ids may share a tag once
+// there are tens of thousands of them. The tag is stable as long as the scheme
+// is, see WithSeed.
+func (o *Obfuscator) ShortTag(id ID) string {
+	mac := hmac.New(sha256.New, o.primary().secret())
+	mac.Write(littleEndian.AppendUint64(nil, id.Uint64()))
+	return Crockford.EncodeToString(mac.Sum(nil)[:4])[:6]
+}
+
+// ShortTag returns the one-way tag of id of the default Obfuscator, see
+// Obfuscator.ShortTag. Unlike String, it can not be turned back into the id.
+// It panics if the default Obfuscator could not be created.
+func (id ID) ShortTag() string { return mustDefault().ShortTag(id) }
@@ -0,0 +1,50 @@
+package goobfuscated
+
+import (
+	"fmt"
+	"reflect"
+	"text/template"
+)
+
+// TemplateFuncs returns the obfuscate and deobfuscate template functions bound
+// to the default Obfuscator, e.g. {{ .UserID | obfuscate }}. html/template
+// accepts the map as well, its FuncMap is an alias of the one of text/template.
+//
+// obfuscate accepts any integer type, ID and named types such as
+// type UserID int64 included, and returns the obfuscated string, deobfuscate
+// accepts the string and returns the raw uint64.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"obfuscate": func(v any) (string, error) {
+			n, err := toUint64(v)
+			if err != nil {
+				return "", err
+			}
+			id := ID(n)
+			text, err := id.MarshalText()
+			return string(text), err
+		},
+		"deobfuscate": func(s string) (uint64, error) {
+			id, err := ParseID(s)
+			return id.Uint64(), err
+		},
+	}
+}
+
+// toUint64 converts the integer v to an uint64, negative values are rejected.
+// Named integer types, e.g. type UserID int64, are converted by their kind.
+func toUint64(v any) (uint64, error) {
+	var n int64
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = rv.Int()
+	default:
+		return 0, fmt.Errorf("can not obfuscate %T", v)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("can not obfuscate negative value %d", n)
+	}
+	return uint64(n), nil
+}
@@ -0,0 +1,28 @@
+package goobfuscated
+
+// TestPrime and TestMask are the fixed scheme of NewTestObfuscator. They are
+// part of the API and never change, so golden files holding obfuscated ids
+// stay valid.
+const (
+	TestPrime = 452977333
+	TestMask  = 0x1f3c5a7e9b2d
+)
+
+// NewTestObfuscator returns an Obfuscator with the fixed scheme TestPrime and
+// TestMask, the default width of 53 bits and the default string form, for the
+// tests of packages embedding ID. Install it as the default in TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		obfuscated.SetDefault(obfuscated.NewTestObfuscator())
+//		os.Exit(m.Run())
+//	}
+//
+// ID(1).String() is then always "mHmBQDwfAAA". The scheme is public, it must
+// not be used outside of tests.
+func NewTestObfuscator() *Obfuscator {
+	o, err := NewObfuscator(WithPrime(TestPrime), WithMask(TestMask), WithSeed(0))
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
@@ -0,0 +1,30 @@
+package goobfuscated
+
+import "testing"
+
+// TestNewTestObfuscator pins the strings of NewTestObfuscator, the golden
+// files of its users hold them.
+func TestNewTestObfuscator(t *testing.T) {
+	resetDefault(t)
+	SetDefault(NewTestObfuscator())
+	for _, tt := range []struct {
+		id   ID
+		want string
+	}{
+		{0, "LZt-WjwfAAA"},
+		{1, "mHmBQDwfAAA"}, // the one of the doc of NewTestObfuscator.
+		{2, "R16BbzwfAAA"},
+		{100, "mRWK0TYfAAA"},
+		{MaxInt, "ZoZ-v8PgHwA"},
+	} {
+		if got := tt.id.String(); got != tt.want {
+			t.Errorf("ID(%d).String() = %q, want %q", tt.id, got, tt.want)
+		}
+		if got, err := StrictParseID(tt.want); err != nil || got != tt.id {
+			t.Errorf("%q parses to %d, %v, want %d", tt.want, got, err, tt.id)
+		}
+	}
+	if o := NewTestObfuscator(); o.prime != TestPrime || o.random != TestMask {
+		t.Errorf("NewTestObfuscator has the prime %d and the mask %#x, want %d and %#x", o.prime, o.random, TestPrime, TestMask)
+	}
+}
@@ -0,0 +1,114 @@
+package goobfuscated
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"time"
+)
+
+// FormatIDWithTTL returns an obfuscated string of id which stops parsing with
+// ParseIDWithTTL once ttl has elapsed, e.g. for self-expiring share links which
+// need no server-side state. Time is cut into buckets of ttl, the string holds
+// the bucket it was formatted in and the obfuscated value masked by a pad of
+// the bucket, so the string of an id changes from bucket to bucket, and a
+// 32 bits HMAC-SHA256 keyed by the scheme of o which detects tampering.
+//
+// The granularity is the bucket: a string stays valid until the end of the
+// bucket following the one it was formatted in, that is between ttl and 2*ttl.
+// A string from the next bucket is accepted as well, so the clocks of the
+// services may drift by up to ttl, a string from further ahead is rejected
+// like an expired one. A string is 18 to 30 characters long in base64,
+// depending on the bucket. It panics if ttl is not positive.
+func (o *Obfuscator) FormatIDWithTTL(id ID, ttl time.Duration) string {
+	return o.formatIDWithTTL(id, ttl, time.Now())
+}
+
+func (o *Obfuscator) formatIDWithTTL(id ID, ttl time.Duration, now time.Time) string {
+	bucket := ttlBucket(ttl, now)
+	pad, mac := o.ttlKeys(ttl, bucket)
+	buf := o.binary(o.Obfuscate(id.Uint64()) ^ pad)
+	mac.Write(buf)
+	buf = mac.Sum(buf)[:12]
+	return o.encode(binary.AppendUvarint(buf, bucket))
+}
+
+// ParseIDWithTTL is an inverse operation of FormatIDWithTTL, ttl must be the
+// one s was formatted with. It returns an error matching ErrExpired if the
+// time to live of s has elapsed, or ErrInvalidFormat if s was not formatted by
+// the scheme of o or has been tampered with. It returns an error if ttl is not
+// positive.
+func (o *Obfuscator) ParseIDWithTTL(s string, ttl time.Duration) (ID, error) {
+	return o.parseIDWithTTL(s, ttl, time.Now())
+}
+
+func (o *Obfuscator) parseIDWithTTL(s string, ttl time.Duration, now time.Time) (ID, error) {
+	if ttl <= 0 {
+		return 0, fmt.Errorf("ttl %v must be positive", ttl)
+	}
+	buf, err := o.appendDecodeMax(nil, s, len(o.prefix)+2*encodedLen(o.enc, ttlMaxBytes))
+	if err != nil {
+		return 0, err
+	}
+	if len(buf) < 13 { // value, checksum and the bucket.
+		return 0, newError(ErrInvalidLength, "unexpected id format")
+	}
+	bucket, n := binary.Uvarint(buf[12:])
+	if n <= 0 || 12+n != len(buf) {
+		return 0, newError(ErrInvalidFormat, "unexpected id format")
+	}
+	pad, mac := o.ttlKeys(ttl, bucket)
+	mac.Write(buf[:8])
+	if !hmac.Equal(mac.Sum(nil)[:4], buf[8:12]) {
+		return 0, newError(ErrInvalidFormat, "id checksum mismatch")
+	}
+	switch current := ttlBucket(ttl, now); {
+	case bucket+1 < current:
+		return 0, newError(ErrExpired, "id expired at %v", time.Unix(0, int64(bucket+2)*int64(ttl)).UTC())
+	case bucket > current+1:
+		return 0, newError(ErrExpired, "id is not valid before %v, the clocks drift by more than %v", time.Unix(0, int64(bucket-1)*int64(ttl)).UTC(), ttl)
+	}
+	id, ok := o.deObfuscate(o.order.Uint64(buf[:8]) ^ pad)
+	if !ok {
+		return 0, ErrOutOfRange
+	}
+	return ID(id), nil
+}
+
+// ttlMaxBytes is the longest string of FormatIDWithTTL in bytes: the value,
+// the checksum and the bucket.
+const ttlMaxBytes = 12 + binary.MaxVarintLen64
+
+// ttlBucket returns the bucket of ttl of now.
+func ttlBucket(ttl time.Duration, now time.Time) uint64 {
+	if ttl <= 0 {
+		panic("goobfuscated: ttl must be positive")
+	}
+	return uint64(now.UnixNano() / int64(ttl))
+}
+
+// ttlKeys returns the pad of the values of bucket and the HMAC of the
+// strings of bucket.
+func (o *Obfuscator) ttlKeys(ttl time.Duration, bucket uint64) (uint64, hash.Hash) {
+	p := o.primary()
+	h := hmac.New(sha256.New, p.secret())
+	h.Write(binary.AppendUvarint(binary.AppendUvarint(nil, uint64(ttl)), bucket))
+	key := h.Sum(nil)
+	return littleEndian.Uint64(key) & p.max, hmac.New(sha256.New, key[8:])
+}
+
+// StringWithTTL returns the obfuscated string of id which expires after ttl,
+// see Obfuscator.FormatIDWithTTL. It panics if the default Obfuscator could
+// not be created.
+func (id ID) StringWithTTL(ttl time.Duration) string { return mustDefault().FormatIDWithTTL(id, ttl) }
+
+// ParseIDWithTTL is an inverse operation of ID.StringWithTTL.
+func ParseIDWithTTL(s string, ttl time.Duration) (ID, error) {
+	o, err := defaultObfuscator()
+	if err != nil {
+		return 0, err
+	}
+	return o.ParseIDWithTTL(s, ttl)
+}
@@ -0,0 +1,54 @@
+package goobfuscated
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// exhaustiveBits is the widest id space VerifyBijection checks exhaustively.
+const exhaustiveBits = 20
+
+// VerifyBijection checks that Obfuscate is a bijection over the id space: no
+// two ids obfuscate to the same value and DeObfuscate(Obfuscate(x)) == x.
+// The bits reserved for a nonce are checked as part of the id, see WithNonce.
+// Id spaces of up to 20 bits (see WithBits) are checked exhaustively, wider
+// ones with sampleSize random ids besides 0 and the upper bound. It returns an
+// error describing the first failure found.
+func (o *Obfuscator) VerifyBijection(sampleSize int) error {
+	p := o.primary()
+	check := func(x uint64, seen map[uint64]uint64) error {
+		n := p.forward(x)
+		if y, ok := seen[n]; ok && y != x {
+			return fmt.Errorf("ids %d and %d both obfuscate to %d", y, x, n)
+		}
+		seen[n] = x
+		if y := p.backward(n); y != x {
+			return fmt.Errorf("id %d does not round-trip, got %d", x, y)
+		}
+		return nil
+	}
+
+	if o.bits <= exhaustiveBits {
+		seen := make(map[uint64]uint64, o.max+1)
+		for x := uint64(0); x <= o.max; x++ {
+			if err := check(x, seen); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	seen := make(map[uint64]uint64, sampleSize+2)
+	for _, x := range []uint64{0, o.max} {
+		if err := check(x, seen); err != nil {
+			return err
+		}
+	}
+	rng := rand.New(rand.NewSource(int64(o.random)))
+	for i := 0; i < sampleSize; i++ {
+		if err := check(rng.Uint64()&o.max, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
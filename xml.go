@@ -0,0 +1,40 @@
+package goobfuscated
+
+import "encoding/xml"
+
+// MarshalXML satisfies xml.Marshaler, the id is emitted as an element holding
+// the obfuscated string, like MarshalText. The zero id is obfuscated like any
+// other id, so it is emitted as a stable non empty string.
+func (id ID) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	text, err := id.MarshalText()
+	if err != nil {
+		return err
+	}
+	return e.EncodeElement(string(text), start)
+}
+
+// UnmarshalXML satisfies xml.Unmarshaler and is the inverse of MarshalXML,
+// the id is reset to zero if the element can not be parsed.
+func (id *ID) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	return id.UnmarshalText([]byte(s))
+}
+
+// MarshalXMLAttr satisfies xml.MarshalerAttr, the id is emitted as an
+// attribute holding the obfuscated string.
+func (id ID) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	text, err := id.MarshalText()
+	if err != nil {
+		return xml.Attr{}, err
+	}
+	return xml.Attr{Name: name, Value: string(text)}, nil
+}
+
+// UnmarshalXMLAttr satisfies xml.UnmarshalerAttr and is the inverse of
+// MarshalXMLAttr.
+func (id *ID) UnmarshalXMLAttr(attr xml.Attr) error {
+	return id.UnmarshalText([]byte(attr.Value))
+}
@@ -0,0 +1,47 @@
+// Package yamlid emits the obfuscated ids in YAML documents of gopkg.in/yaml.v3
+// as double-quoted scalars, it is kept apart from goobfuscated so that only its
+// users depend on yaml.v3.
+//
+// goobfuscated.ID already reads and writes YAML through MarshalText and
+// UnmarshalText, as a plain scalar: an id starting with "-" or "_" is emitted
+// unquoted. It is still a string in YAML, a dash is only a sequence marker
+// when a space follows it, and yaml.v3 reads it back, so a bare ID needs no
+// change. The quoted form is for the documents edited
+// by hand or read by less strict parsers. Controlling the style takes the
+// Node of yaml.v3 and the root package only depends on the standard library,
+// so the methods can not be declared on goobfuscated.ID: ID wraps it, and the
+// fields to quote declare the type of this package instead.
+package yamlid
+
+import (
+	"fmt"
+
+	"github.com/19byte/goobfuscated"
+	"gopkg.in/yaml.v3"
+)
+
+// ID is a goobfuscated.ID whose YAML form is a double-quoted scalar, e.g. for
+// the fields of configuration files edited by hand. The other methods of the
+// embedded ID, JSON, SQL and text, are promoted, so the field keeps its other
+// forms. Convert with ID{id} and id.ID.
+type ID struct{ goobfuscated.ID }
+
+// MarshalYAML satisfies yaml.Marshaler of gopkg.in/yaml.v3, the obfuscated
+// string is emitted as a double-quoted scalar. A base64 id may start with "-"
+// or "_", quoting keeps YAML from reading it as anything else but a string.
+func (id ID) MarshalYAML() (any, error) {
+	text, err := id.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Tag: "!!str", Value: string(text)}, nil
+}
+
+// UnmarshalYAML satisfies yaml.Unmarshaler of gopkg.in/yaml.v3 and is the
+// inverse of MarshalYAML, it accepts both plain and quoted scalars.
+func (id *ID) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("fails to decode id: line %d: expected a scalar: %w", value.Line, goobfuscated.ErrInvalidFormat)
+	}
+	return id.UnmarshalText([]byte(value.Value))
+}
@@ -0,0 +1,95 @@
+package yamlid
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/19byte/goobfuscated"
+	"gopkg.in/yaml.v3"
+)
+
+// idsStartingWith returns an id whose string form starts with each of the
+// given characters.
+func idsStartingWith(t *testing.T, chars string) []goobfuscated.ID {
+	t.Helper()
+	ids := make([]goobfuscated.ID, 0, len(chars))
+	for _, c := range chars {
+		for id := goobfuscated.ID(1); ; id++ {
+			if id > 1<<20 {
+				t.Fatalf("no id starts with %q", c)
+			}
+			if strings.HasPrefix(id.String(), string(c)) {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+func TestYAMLLeadingDashOrUnderscore(t *testing.T) {
+	o, err := goobfuscated.NewObfuscator(goobfuscated.WithSeed(20200101))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goobfuscated.SetDefault(o)
+	type doc struct {
+		ID ID `yaml:"id"`
+	}
+	type rawDoc struct {
+		ID goobfuscated.ID `yaml:"id"`
+	}
+	for _, id := range idsStartingWith(t, "-_") {
+		b, err := yaml.Marshal(doc{ID{id}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "id: \"" + id.String() + "\"\n"; string(b) != want {
+			t.Errorf("%d marshals to %q, want %q", id, b, want)
+		}
+		var got doc
+		if err := yaml.Unmarshal(b, &got); err != nil || got.ID.ID != id {
+			t.Errorf("%q unmarshals to %d, %v, want %d", b, got.ID.ID, err, id)
+		}
+		// The plain scalar of a bare goobfuscated.ID is read back as well.
+		b, err = yaml.Marshal(rawDoc{id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "id: " + id.String() + "\n"; string(b) != want {
+			t.Errorf("bare %d marshals to %q, want the plain %q", id, b, want)
+		}
+		var raw rawDoc
+		if err := yaml.Unmarshal(b, &raw); err != nil || raw.ID != id {
+			t.Errorf("%q unmarshals to %d, %v, want %d", b, raw.ID, err, id)
+		}
+	}
+}
+
+// TestYAMLSequence checks that the plain scalars of bare ids starting with
+// "-" are not taken for nested sequences in a block sequence.
+func TestYAMLSequence(t *testing.T) {
+	o, err := goobfuscated.NewObfuscator(goobfuscated.WithSeed(20200101))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goobfuscated.SetDefault(o)
+	ids := idsStartingWith(t, "-_")
+	b, err := yaml.Marshal(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []goobfuscated.ID
+	if err := yaml.Unmarshal(b, &got); err != nil || len(got) != len(ids) || got[0] != ids[0] || got[1] != ids[1] {
+		t.Errorf("%q unmarshals to %v, %v, want %d", b, got, err, ids)
+	}
+}
+
+func TestYAMLNotAScalar(t *testing.T) {
+	var got struct {
+		ID ID `yaml:"id"`
+	}
+	if err := yaml.Unmarshal([]byte("id: [1, 2]\n"), &got); err == nil {
+		t.Error("a sequence unmarshals to an id")
+	}
+}